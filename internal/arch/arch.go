@@ -24,6 +24,8 @@ type Architecture interface {
 	// This is used in systems where the last address is reserved for
 	// the interrupt vector table.
 	LastCodeAddress() uint16
+	// PostProcess runs the pattern detectors over the decoded code after the execution flow has been followed.
+	PostProcess(dis Disasm)
 	// ProcessOffset processes an offset and returns if the offset was processed and an error if any.
 	ProcessOffset(dis Disasm, address uint16, offsetInfo *Offset) (bool, error)
 	// ProcessVariableUsage processes the variable usage of an offset.
@@ -36,6 +38,7 @@ type Architecture interface {
 // This is used to replace the parameter of an instruction by a constant name.
 type Constant struct {
 	Address uint16
+	Group   string // name of the subsystem that the constant belongs to, used for grouping the output
 
 	Read  string
 	Write string
@@ -11,6 +11,12 @@ import (
 type Disasm interface {
 	// AddAddressToParse adds an address to the list to be processed if the address has not been processed yet.
 	AddAddressToParse(address, context, from uint16, currentInstruction Instruction, isABranchDestination bool)
+	// AddUnreachableAddressToParse adds an address that is only reachable if the instruction at the from
+	// address is a branch destination. This is checked after all other addresses have been parsed, once
+	// all branch destinations are known.
+	AddUnreachableAddressToParse(address, context, from uint16, currentInstruction Instruction)
+	// AddWarning counts a suspicious disassembly result for the quality metrics.
+	AddWarning(warning Warning)
 	// Cart returns the loaded cartridge.
 	Cart() *cartridge.Cartridge
 	// ChangeAddressRangeToCodeAsData sets a range of code address to code as
@@ -45,3 +51,12 @@ type Disasm interface {
 	// Variables returns the variable manager.
 	Variables() VariableManager
 }
+
+// Warning defines a kind of suspicious disassembly result that indicates data decoded as code.
+type Warning int
+
+// Warning kinds that are counted for the quality metrics.
+const (
+	BankCrossingWarning   Warning = iota // branch or jump into a different bank window
+	StackImbalanceWarning                // unbalanced stack pushes and pulls along a code path
+)
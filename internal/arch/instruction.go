@@ -2,6 +2,9 @@ package arch
 
 // Instruction represents a CPU instruction.
 type Instruction interface {
+	// ContinuesExecution returns true if the execution continues with the following instruction,
+	// calls are expected to return.
+	ContinuesExecution() bool
 	// IsCall returns true if the instruction is a call.
 	IsCall() bool
 	// IsNil returns true if the instruction is nil.
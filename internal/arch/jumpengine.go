@@ -8,7 +8,8 @@ type JumpEngine interface {
 	// and returns data references that could point to the function table.
 	GetContextDataReferences(dis Disasm, offsets []*Offset, addresses []uint16) ([]uint16, error)
 	// GetFunctionTableReference detects a jump engine function context and its function table.
-	GetFunctionTableReference(context uint16, dataReferences []uint16)
+	// The number of table entries is used to limit the table size if it is known, 0 otherwise.
+	GetFunctionTableReference(context uint16, dataReferences []uint16, tableEntries int)
 	// HandleJumpEngineDestination processes a newly detected jump engine destination.
 	HandleJumpEngineDestination(dis Disasm, caller, destination uint16) error
 	// HandleJumpEngineCallers processes all callers of a newly detected jump engine function.
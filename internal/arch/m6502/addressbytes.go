@@ -0,0 +1,72 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/assembler"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// detectAddressBytes detects the low and high byte of a code address that get loaded as immediate
+// values and stored to adjacent addresses, which sets up a pointer or an indirect jump vector.
+// The immediate operands get output as the byte select operators of the assembler syntax applied
+// to the label of the code address.
+func detectAddressBytes(dis arch.Disasm, syntax assembler.Syntax, instructions []instructionInfo) {
+	for i := 0; i+3 < len(instructions); i++ {
+		if !isStraightLine(instructions, i, i+3) {
+			continue
+		}
+
+		first, firstAddress, ok := immediateAddressByteStore(instructions, i+1)
+		if !ok {
+			continue
+		}
+		second, secondAddress, ok := immediateAddressByteStore(instructions, i+3)
+		if !ok {
+			continue
+		}
+
+		low, high := instructions[i], instructions[i+2]
+		switch {
+		case secondAddress == firstAddress+1:
+		case firstAddress == secondAddress+1:
+			low, high = high, low
+			first, second = second, first
+		default:
+			continue
+		}
+
+		target := second<<8 | first
+		if !isCodeAddress(dis, target) {
+			continue
+		}
+
+		targetInfo := dis.Mapper().OffsetInfo(target)
+		dis.AddAddressToParse(target, targetInfo.Context, low.address, nil, true)
+		dis.AddAddressToParse(target, targetInfo.Context, high.address, nil, true)
+		low.offsetInfo.OperandFormat = "#" + syntax.LowByteOf("%s")
+		high.offsetInfo.OperandFormat = "#" + syntax.HighByteOf("%s")
+		i += 3
+	}
+}
+
+// immediateAddressByteStore returns the stored value and the destination address if the store
+// instruction at the given index writes an immediate value that was loaded by the previous
+// instruction to a zero page or absolute address.
+func immediateAddressByteStore(instructions []instructionInfo, index int) (uint16, uint16, bool) {
+	store := instructions[index]
+	if store.addressing != m6502.ZeroPageAddressing && store.addressing != m6502.AbsoluteAddressing {
+		return 0, 0, false
+	}
+	value, ok := immediateStoreValue(instructions, index)
+	return value, store.operand(), ok
+}
+
+// isCodeAddress returns whether the address is the start of a decoded instruction.
+func isCodeAddress(dis arch.Disasm, address uint16) bool {
+	if address < dis.CodeBaseAddress() || address >= m6502.InterruptVectorStartAddress {
+		return false
+	}
+	offsetInfo := dis.Mapper().OffsetInfo(address)
+	return offsetInfo != nil && offsetInfo.IsType(program.CodeOffset) && len(offsetInfo.Data) > 0
+}
@@ -1,10 +1,29 @@
 package m6502
 
 import (
+	"fmt"
+
 	"github.com/retroenv/nesgodisasm/internal/arch"
 	"github.com/retroenv/retrogolib/arch/cpu/m6502"
 )
 
+// addressingNames maps the addressing modes to a human readable name.
+var addressingNames = map[m6502.AddressingMode]string{
+	m6502.ImpliedAddressing:     "implied",
+	m6502.AccumulatorAddressing: "accumulator",
+	m6502.ImmediateAddressing:   "immediate",
+	m6502.AbsoluteAddressing:    "absolute",
+	m6502.ZeroPageAddressing:    "zeropage",
+	m6502.AbsoluteXAddressing:   "absolute,X",
+	m6502.ZeroPageXAddressing:   "zeropage,X",
+	m6502.AbsoluteYAddressing:   "absolute,Y",
+	m6502.ZeroPageYAddressing:   "zeropage,Y",
+	m6502.IndirectAddressing:    "indirect",
+	m6502.IndirectXAddressing:   "(indirect,X)",
+	m6502.IndirectYAddressing:   "(indirect),Y",
+	m6502.RelativeAddressing:    "relative",
+}
+
 // GetAddressingParam returns the address of the param if it references an address.
 func (ar *Arch6502) GetAddressingParam(param any) (uint16, bool) {
 	switch val := param.(type) {
@@ -43,3 +62,24 @@ func (ar *Arch6502) IsAddressingIndexed(opcode arch.Opcode) bool {
 		return false
 	}
 }
+
+// indexedBaseComment returns a comment that describes the base address and index register
+// of an absolute indexed access.
+func indexedBaseComment(addressing m6502.AddressingMode, base string) (string, bool) {
+	switch addressing {
+	case m6502.AbsoluteXAddressing:
+		return fmt.Sprintf("base=%s, +X", base), true
+	case m6502.AbsoluteYAddressing:
+		return fmt.Sprintf("base=%s, +Y", base), true
+	default:
+		return "", false
+	}
+}
+
+// operandWord returns the 16 bit operand of an instruction that uses an absolute addressing mode.
+func operandWord(data []byte) uint16 {
+	if len(data) < 3 {
+		return 0
+	}
+	return uint16(data[2])<<8 | uint16(data[1])
+}
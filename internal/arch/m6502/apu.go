@@ -0,0 +1,85 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+	"github.com/retroenv/retrogolib/arch/nes"
+	"github.com/retroenv/retrogolib/arch/nes/register"
+)
+
+const apuSilenceComment = "silence APU"
+
+// apuRegisterFunctions maps the APU channel register addresses to the channel and function of
+// the register when written.
+var apuRegisterFunctions = map[uint16]string{
+	0x4000: "pulse1 duty/volume",
+	0x4001: "pulse1 sweep",
+	0x4002: "pulse1 timer lo",
+	0x4003: "pulse1 timer hi/length",
+	0x4004: "pulse2 duty/volume",
+	0x4005: "pulse2 sweep",
+	0x4006: "pulse2 timer lo",
+	0x4007: "pulse2 timer hi/length",
+	0x4008: "triangle linear counter",
+	0x400a: "triangle timer lo",
+	0x400b: "triangle timer hi/length",
+	0x400c: "noise volume",
+	0x400e: "noise mode/period",
+	0x400f: "noise length",
+	0x4010: "DMC flags/rate",
+	0x4011: "DMC direct load",
+	0x4012: "DMC sample address",
+	0x4013: "DMC sample length",
+}
+
+// annotateAPUWrites annotates all writes to the APU channel registers with the channel and the
+// function of the written register.
+func annotateAPUWrites(instructions []instructionInfo) {
+	for _, ins := range instructions {
+		if _, ok := storeLoads[ins.name]; !ok || ins.addressing != m6502.AbsoluteAddressing {
+			continue
+		}
+
+		if function, ok := apuRegisterFunctions[ins.operand()]; ok {
+			ins.offsetInfo.AddComment(function)
+		}
+	}
+}
+
+// detectAPUSilence annotates the write of zero to the APU channel enable register in the reset
+// handler, which disables all audio channels as part of the initialization.
+func detectAPUSilence(dis arch.Disasm, instructions []instructionInfo) {
+	reset, err := resetAddress(dis)
+	if err != nil {
+		return
+	}
+
+	for i, ins := range instructions {
+		if ins.offsetInfo.Context != reset {
+			continue
+		}
+		if _, ok := storeLoads[ins.name]; !ok || ins.addressing != m6502.AbsoluteAddressing ||
+			ins.operand() != register.APU_SND_CHN {
+
+			continue
+		}
+
+		if value, ok := immediateStoreValue(instructions, i); ok && value == 0 {
+			ins.offsetInfo.AddComment(apuSilenceComment)
+		}
+	}
+}
+
+// resetAddress returns the address of the reset handler.
+func resetAddress(dis arch.Disasm) (uint16, error) {
+	if dis.Options().Binary {
+		return nes.CodeBaseAddress, nil
+	}
+	address, err := dis.ReadMemoryWord(m6502.ResetAddress)
+	if err != nil {
+		return 0, fmt.Errorf("reading reset address: %w", err)
+	}
+	return address, nil
+}
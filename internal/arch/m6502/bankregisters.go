@@ -0,0 +1,107 @@
+package m6502
+
+// bankRegister is an address range of a mapper register that switches a bank by a single write
+// of the bank number.
+type bankRegister struct {
+	start   uint16
+	end     uint16
+	oddOnly bool // only the odd addresses of the range are mapped to the register
+
+	window     uint16 // start address of the switched PRG window, 0 if it is selected by another register
+	windowSize int    // size of the switched PRG window and of the banks that can be mapped into it
+}
+
+// prgBankRegisters contains the registers of the supported mappers that switch a PRG bank with
+// the written value being the bank number. MMC1 is not supported as it uses a serial port that
+// needs multiple writes for a single bank number, mappers that combine the PRG and CHR bank
+// numbers in a single register are not supported either.
+var prgBankRegisters = map[byte][]bankRegister{
+	2:  {{start: 0x8000, end: 0xffff, window: 0x8000, windowSize: 0x4000}}, // UxROM
+	4:  {{start: 0x8000, end: 0x9fff, oddOnly: true}},                      // MMC3 bank data
+	5:  {{start: 0x5114, end: 0x5117}},                                     // MMC5
+	7:  {{start: 0x8000, end: 0xffff, window: 0x8000, windowSize: 0x8000}}, // AxROM
+	9:  {{start: 0xa000, end: 0xafff, window: 0x8000, windowSize: 0x2000}}, // MMC2
+	10: {{start: 0xa000, end: 0xafff, window: 0x8000, windowSize: 0x4000}}, // MMC4
+	19: {{start: 0xe000, end: 0xf7ff}},                                     // Namco 163
+	21: {{start: 0x8000, end: 0x8fff, window: 0x8000, windowSize: 0x2000},
+		{start: 0xa000, end: 0xafff, window: 0xa000, windowSize: 0x2000}}, // VRC4
+	22: {{start: 0x8000, end: 0x8fff, window: 0x8000, windowSize: 0x2000},
+		{start: 0xa000, end: 0xafff, window: 0xa000, windowSize: 0x2000}}, // VRC2
+	23: {{start: 0x8000, end: 0x8fff, window: 0x8000, windowSize: 0x2000},
+		{start: 0xa000, end: 0xafff, window: 0xa000, windowSize: 0x2000}}, // VRC2/VRC4
+	24: {{start: 0x8000, end: 0x8fff, window: 0x8000, windowSize: 0x4000},
+		{start: 0xc000, end: 0xcfff, window: 0xc000, windowSize: 0x2000}}, // VRC6
+	25: {{start: 0x8000, end: 0x8fff, window: 0x8000, windowSize: 0x2000},
+		{start: 0xa000, end: 0xafff, window: 0xa000, windowSize: 0x2000}}, // VRC2/VRC4
+	26: {{start: 0x8000, end: 0x8fff, window: 0x8000, windowSize: 0x4000},
+		{start: 0xc000, end: 0xcfff, window: 0xc000, windowSize: 0x2000}}, // VRC6
+	34: {{start: 0x8000, end: 0xffff, window: 0x8000, windowSize: 0x8000}}, // BNROM
+	69: {{start: 0xa000, end: 0xbfff}},                                     // FME-7 parameter
+}
+
+// chrBankRegisters contains the registers of the supported mappers that switch a CHR bank with
+// the written value being the bank number.
+var chrBankRegisters = map[byte][]bankRegister{
+	3:  {{start: 0x8000, end: 0xffff}},                // CNROM
+	4:  {{start: 0x8000, end: 0x9fff, oddOnly: true}}, // MMC3 bank data
+	5:  {{start: 0x5120, end: 0x512b}},                // MMC5
+	9:  {{start: 0xb000, end: 0xefff}},                // MMC2
+	10: {{start: 0xb000, end: 0xefff}},                // MMC4
+	19: {{start: 0x8000, end: 0xbfff}},                // Namco 163
+	21: {{start: 0xb000, end: 0xefff}},                // VRC4
+	22: {{start: 0xb000, end: 0xefff}},                // VRC2
+	23: {{start: 0xb000, end: 0xefff}},                // VRC2/VRC4
+	24: {{start: 0xd000, end: 0xefff}},                // VRC6
+	25: {{start: 0xb000, end: 0xefff}},                // VRC2/VRC4
+	26: {{start: 0xd000, end: 0xefff}},                // VRC6
+	69: {{start: 0xa000, end: 0xbfff}},                // FME-7 parameter
+}
+
+// combinedBankRegisters contains the registers of the supported mappers that switch the PRG and
+// CHR bank by a single write of both bank numbers.
+var combinedBankRegisters = map[byte][]bankRegister{
+	11: {{start: 0x8000, end: 0xffff}}, // Color Dreams
+	66: {{start: 0x8000, end: 0xffff}}, // GxROM
+}
+
+// isPRGBankRegister returns whether the address is a register of the given mapper that switches
+// a PRG bank.
+func isPRGBankRegister(mapper byte, address uint16) bool {
+	return isBankRegister(prgBankRegisters[mapper], address)
+}
+
+// prgBankRegister returns the PRG bank register of the given mapper that the address is mapped to.
+func prgBankRegister(mapper byte, address uint16) (bankRegister, bool) {
+	for _, register := range prgBankRegisters[mapper] {
+		if register.contains(address) {
+			return register, true
+		}
+	}
+	return bankRegister{}, false
+}
+
+// isBankSelectRegister returns whether the address is a register of the given mapper that
+// switches any PRG or CHR bank by a single write.
+func isBankSelectRegister(mapper byte, address uint16) bool {
+	return isPRGBankRegister(mapper, address) ||
+		isBankRegister(chrBankRegisters[mapper], address) ||
+		isBankRegister(combinedBankRegisters[mapper], address)
+}
+
+// isBankRegister returns whether the address is mapped to one of the given registers.
+func isBankRegister(registers []bankRegister, address uint16) bool {
+	for _, register := range registers {
+		if register.contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains returns whether the address is mapped to the register.
+func (r bankRegister) contains(address uint16) bool {
+	if address < r.start || address > r.end {
+		return false
+	}
+	return !r.oddOnly || address&1 == 1
+}
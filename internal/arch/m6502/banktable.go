@@ -0,0 +1,81 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	bankTableNaming       = "bank_table_%04x"
+	bankTableWriteComment = "bank switch via lookup table"
+)
+
+// detectBankTableSwitches detects bank switches that write a bank number that was loaded from an
+// indexed table in ROM to a bank select register of the mapper. The table gets labeled and the
+// write annotated. Writing the value back to the same table entry is a common way to avoid bus
+// conflicts of discrete logic mappers, such a write is detected as well.
+func detectBankTableSwitches(dis arch.Disasm, instructions []instructionInfo) {
+	mapper := dis.Mapper()
+	mapperNumber := dis.Cart().Mapper
+
+	for i, ins := range instructions {
+		if !isMapperBankSelectWrite(mapperNumber, ins) {
+			continue
+		}
+
+		table, ok := bankTableLoad(dis, instructions, i)
+		if !ok {
+			continue
+		}
+
+		offsetInfo := mapper.OffsetInfo(table)
+		if offsetInfo == nil || offsetInfo.IsType(program.CodeOffset|program.CodeAsData) {
+			continue
+		}
+		if offsetInfo.Label == "" {
+			offsetInfo.Label = fmt.Sprintf(bankTableNaming, table)
+		}
+		ins.offsetInfo.AddComment(bankTableWriteComment)
+	}
+}
+
+// isMapperBankSelectWrite returns whether the instruction writes a register to a bank number
+// register of the given mapper. MMC1 is not supported as it uses a serial port that needs
+// multiple writes for a single bank number.
+func isMapperBankSelectWrite(mapper byte, ins instructionInfo) bool {
+	if _, ok := storeLoads[ins.name]; !ok {
+		return false
+	}
+	if ins.addressing != m6502.AbsoluteAddressing && ins.addressing != m6502.AbsoluteXAddressing &&
+		ins.addressing != m6502.AbsoluteYAddressing {
+
+		return false
+	}
+	return isBankSelectRegister(mapper, ins.operand())
+}
+
+// bankTableLoad returns the address of the table that the register written by the store
+// instruction at the given index was loaded from by the directly preceding instruction.
+func bankTableLoad(dis arch.Disasm, instructions []instructionInfo, index int) (uint16, bool) {
+	if index == 0 {
+		return 0, false
+	}
+
+	store := instructions[index]
+	load := instructions[index-1]
+	if !store.follows(load) || load.name != storeLoads[store.name] {
+		return 0, false
+	}
+	if load.addressing != m6502.AbsoluteXAddressing && load.addressing != m6502.AbsoluteYAddressing {
+		return 0, false
+	}
+
+	table := load.operand()
+	if table < dis.CodeBaseAddress() || table >= m6502.InterruptVectorStartAddress {
+		return 0, false
+	}
+	return table, true
+}
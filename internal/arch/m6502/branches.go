@@ -0,0 +1,65 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/options"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// complementaryBranches maps every conditional branch instruction to the branch instruction that
+// branches on the opposite condition.
+var complementaryBranches = map[string]string{
+	m6502.Bcc.Name: m6502.Bcs.Name,
+	m6502.Bcs.Name: m6502.Bcc.Name,
+	m6502.Beq.Name: m6502.Bne.Name,
+	m6502.Bne.Name: m6502.Beq.Name,
+	m6502.Bmi.Name: m6502.Bpl.Name,
+	m6502.Bpl.Name: m6502.Bmi.Name,
+	m6502.Bvc.Name: m6502.Bvs.Name,
+	m6502.Bvs.Name: m6502.Bvc.Name,
+}
+
+// isComplementaryBranch returns whether the instruction at the given address is a conditional branch
+// that directly follows a branch on the opposite condition. One of both branches is always taken,
+// which makes the pair behave like an unconditional jump, unless the second branch is a branch
+// destination itself. As not all branch destinations are known while following the execution flow,
+// the caller has to check this once the execution flow has been followed.
+func isComplementaryBranch(dis arch.Disasm, address uint16, offsetInfo *arch.Offset) bool {
+	complement, ok := complementaryBranches[offsetInfo.Opcode.Instruction().Name()]
+	if !ok || address < dis.CodeBaseAddress()+2 {
+		return false
+	}
+
+	previous := dis.Mapper().OffsetInfo(address - 2)
+	if previous == nil || previous.Opcode == nil || len(previous.Data) != 2 {
+		return false
+	}
+	return previous.Opcode.Instruction().Name() == complement
+}
+
+// processComplementaryBranches marks the code following a complementary branch pair as unreachable,
+// if it is not referenced by any other code. The code keeps being output as code with a comment.
+func processComplementaryBranches(dis arch.Disasm, instructions []instructionInfo) {
+	opts := dis.Options()
+	if opts.Unreachable != options.UnreachableCode {
+		return
+	}
+
+	for i := 1; i+1 < len(instructions); i++ {
+		previous := instructions[i-1]
+		branch := instructions[i]
+		if !branch.follows(previous) || complementaryBranches[branch.name] != previous.name {
+			continue
+		}
+
+		following := instructions[i+1]
+		if following.follows(branch) {
+			markAddressAsUnreachable(following.offsetInfo, opts.UnreachableComment)
+		}
+	}
+}
+
+// markAddressAsUnreachable marks the offset as not being reachable by the execution flow.
+func markAddressAsUnreachable(offsetInfo *arch.Offset, comment string) {
+	offsetInfo.AddComment(comment)
+}
@@ -0,0 +1,80 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const checksumLoopComment = "ROM checksum loop"
+
+// checksumLoopMaxSize is the maximum number of instructions that are checked after the read of
+// a PRG byte for the accumulation, the index change and the branch back to the loop start.
+const checksumLoopMaxSize = 6
+
+// checksumAccumulators contains the instructions that accumulate the read bytes into the checksum.
+var checksumAccumulators = map[string]struct{}{
+	m6502.Adc.Name: {},
+	m6502.Eor.Name: {},
+}
+
+// detectChecksumLoops detects loops that read sequential PRG bytes and accumulate them by an
+// addition or exclusive or, which is the idiom of a ROM self check. The read is annotated.
+func detectChecksumLoops(dis arch.Disasm, instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if !isSequentialPRGRead(dis, ins) {
+			continue
+		}
+		if checksumLoop(instructions, i) {
+			ins.offsetInfo.AddComment(checksumLoopComment)
+		}
+	}
+}
+
+// isSequentialPRGRead returns whether the instruction loads or accumulates an indexed byte that
+// can be part of the PRG. Indirect reads are accepted as the pointer is set up at runtime.
+func isSequentialPRGRead(dis arch.Disasm, ins instructionInfo) bool {
+	if _, ok := checksumAccumulators[ins.name]; !ok && ins.name != m6502.Lda.Name {
+		return false
+	}
+
+	switch ins.addressing {
+	case m6502.IndirectYAddressing:
+		return true
+	case m6502.AbsoluteXAddressing, m6502.AbsoluteYAddressing:
+		return ins.operand() >= dis.CodeBaseAddress()
+	default:
+		return false
+	}
+}
+
+// checksumLoop returns whether the read at the given index is followed by an accumulation of the
+// read byte, a change of the index register and a branch back to the read or before it.
+func checksumLoop(instructions []instructionInfo, readIndex int) bool {
+	read := instructions[readIndex]
+	_, accumulated := checksumAccumulators[read.name]
+	var indexChanged bool
+
+	for i := readIndex + 1; i < len(instructions) && i <= readIndex+checksumLoopMaxSize; i++ {
+		ins := instructions[i]
+		if !continuesBlock(instructions[i-1], ins) {
+			return false
+		}
+
+		switch {
+		case ins.addressing == m6502.RelativeAddressing:
+			if ins.branchTarget() <= read.address {
+				return accumulated && indexChanged
+			}
+
+		case ins.name == m6502.Inx.Name, ins.name == m6502.Iny.Name,
+			ins.name == m6502.Dex.Name, ins.name == m6502.Dey.Name:
+			indexChanged = true
+
+		default:
+			if _, ok := checksumAccumulators[ins.name]; ok && ins.addressing != m6502.ImmediateAddressing {
+				accumulated = true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const codeTableComment = "jump/call table"
+
+// codeTableMinEntries is the minimum number of uniform jump or call instructions that form a table.
+const codeTableMinEntries = 3
+
+// codeTableEntrySize is the size of an absolute jump or call instruction.
+const codeTableEntrySize = 3
+
+// jumpAbsoluteOpcode is the opcode of the absolute jump instruction.
+const jumpAbsoluteOpcode = 0x4C
+
+// checkForJumpTable checks whether the absolute jump instruction at the given address is the
+// first entry of a table of absolute jumps that are executed by a computed offset. As a jump does
+// not continue the execution at the following instruction, all further entries of the table are
+// added for parsing to follow all their targets.
+func (ar *Arch6502) checkForJumpTable(dis arch.Disasm, jumpAddress uint16, offsetInfo *arch.Offset) error {
+	if len(offsetInfo.Data) != codeTableEntrySize || offsetInfo.Data[0] != jumpAbsoluteOpcode {
+		return nil
+	}
+
+	var entries []uint16
+	for address := uint32(jumpAddress) + codeTableEntrySize; ; address += codeTableEntrySize {
+		ok, err := isJumpTableEntry(dis, address)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		entries = append(entries, uint16(address))
+	}
+	if len(entries) < codeTableMinEntries-1 {
+		return nil
+	}
+
+	for _, address := range entries {
+		dis.AddAddressToParse(address, offsetInfo.Context, jumpAddress, offsetInfo.Opcode.Instruction(), false)
+	}
+	return nil
+}
+
+// isJumpTableEntry returns whether the bytes at the given address are an absolute jump into the code.
+func isJumpTableEntry(dis arch.Disasm, address uint32) (bool, error) {
+	if address+codeTableEntrySize > m6502.InterruptVectorStartAddress {
+		return false, nil
+	}
+
+	b, err := dis.ReadMemory(uint16(address))
+	if err != nil {
+		return false, fmt.Errorf("reading memory at address %04x: %w", address, err)
+	}
+	if b != jumpAbsoluteOpcode {
+		return false, nil
+	}
+
+	target, err := dis.ReadMemoryWord(uint16(address + 1))
+	if err != nil {
+		return false, fmt.Errorf("reading memory at address %04x: %w", address+1, err)
+	}
+	return target >= dis.CodeBaseAddress() && target < m6502.InterruptVectorStartAddress, nil
+}
+
+// detectCodeTables detects contiguous runs of uniform absolute jump or call instructions that
+// can not be reached by the execution of a previous instruction and annotates the first entry.
+// Such tables are entered by a computed offset. A run of calls that starts a called function is
+// the normal sequence of a function calling subroutines and is ignored.
+func detectCodeTables(instructions []instructionInfo) {
+	for i := 0; i < len(instructions); i++ {
+		ins := instructions[i]
+		if !isCodeTableEntry(ins) || (i > 0 && continuesBlock(instructions[i-1], ins)) {
+			continue
+		}
+		if ins.name == m6502.Jsr.Name && ins.offsetInfo.IsType(program.CallDestination) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(instructions) && instructions[end].name == ins.name &&
+			isCodeTableEntry(instructions[end]) &&
+			instructions[end-1].address+codeTableEntrySize == instructions[end].address {
+
+			end++
+		}
+		if end-i >= codeTableMinEntries {
+			ins.offsetInfo.AddComment(codeTableComment)
+		}
+		i = end - 1
+	}
+}
+
+// isCodeTableEntry returns whether the instruction is an absolute jump or call.
+func isCodeTableEntry(ins instructionInfo) bool {
+	if ins.addressing != m6502.AbsoluteAddressing {
+		return false
+	}
+	return ins.name == m6502.Jmp.Name || ins.name == m6502.Jsr.Name
+}
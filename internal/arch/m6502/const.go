@@ -2,6 +2,7 @@ package m6502
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/retroenv/nesgodisasm/internal/arch"
 	"github.com/retroenv/retrogolib/arch/cpu/m6502"
@@ -12,22 +13,35 @@ import (
 // modules that maps an address to a constant name.
 func (ar *Arch6502) Constants() (map[uint16]arch.Constant, error) {
 	m := map[uint16]arch.Constant{}
-	if err := mergeConstantsMaps(m, register.APUAddressToName); err != nil {
+	if err := mergeConstantsMaps(m, register.APUAddressToName, "APU registers"); err != nil {
 		return nil, fmt.Errorf("processing apu constants: %w", err)
 	}
-	if err := mergeConstantsMaps(m, register.ControllerAddressToName); err != nil {
+	if err := mergeConstantsMaps(m, register.ControllerAddressToName, "Controller"); err != nil {
 		return nil, fmt.Errorf("processing controller constants: %w", err)
 	}
-	if err := mergeConstantsMaps(m, register.PPUAddressToName); err != nil {
+	if err := mergeConstantsMaps(m, register.PPUAddressToName, "PPU registers"); err != nil {
 		return nil, fmt.Errorf("processing ppu constants: %w", err)
 	}
 	return m, nil
 }
 
-func mergeConstantsMaps(destination map[uint16]arch.Constant, source map[uint16]m6502.AccessModeConstant) error {
-	for address, constantInfo := range source {
+func mergeConstantsMaps(destination map[uint16]arch.Constant, source map[uint16]m6502.AccessModeConstant,
+	group string) error {
+
+	// process the addresses sorted to report duplicate definitions deterministically
+	addresses := make([]uint16, 0, len(source))
+	for address := range source {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+
+	for _, address := range addresses {
+		constantInfo := source[address]
 		translation := destination[address]
 		translation.Address = address
+		if translation.Group == "" {
+			translation.Group = group
+		}
 
 		if constantInfo.Mode&m6502.ReadAccess != 0 {
 			if translation.Read != "" {
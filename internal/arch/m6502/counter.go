@@ -0,0 +1,80 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const countedLoopComment = "%d iterations"
+
+// annotateCountedLoops annotates loops that change an index register, which is initialized with
+// an immediate value before the loop, and branch back until the register reaches zero or the
+// compared end value. The branch back to the loop start gets annotated with the number of iterations.
+func annotateCountedLoops(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if ins.name != m6502.Bne.Name || ins.branchTarget() >= ins.address {
+			continue
+		}
+		if iterations, ok := countedLoopIterations(instructions, i); ok {
+			ins.offsetInfo.AddComment(fmt.Sprintf(countedLoopComment, iterations))
+		}
+	}
+}
+
+// countedLoopIterations returns the number of iterations of the loop that ends with the branch at
+// the given index. The change of the counter register and the optional compare have to directly
+// precede the branch, the register must not be changed anywhere else in the loop and the loop
+// must not be left by another branch.
+// nolint: cyclop
+func countedLoopIterations(instructions []instructionInfo, branchIndex int) (int, bool) {
+	counterIndex := branchIndex - 1
+	var compare *instructionInfo
+	if ins := &instructions[counterIndex]; (ins.name == m6502.Cpx.Name || ins.name == m6502.Cpy.Name) &&
+		ins.addressing == m6502.ImmediateAddressing {
+
+		compare = ins
+		counterIndex--
+	}
+	if counterIndex < 1 {
+		return 0, false
+	}
+
+	counter := instructions[counterIndex]
+	switch counter.name {
+	case m6502.Inx.Name, m6502.Iny.Name, m6502.Dex.Name, m6502.Dey.Name:
+	default:
+		return 0, false
+	}
+	register := indexRegister(counter.name)
+	if compare != nil && indexRegister(compare.name) != register {
+		return 0, false
+	}
+
+	startIndex := instructionIndex(instructions, instructions[branchIndex].branchTarget())
+	if startIndex < 0 || startIndex > counterIndex {
+		return 0, false
+	}
+	for i := startIndex; i <= branchIndex; i++ {
+		ins := instructions[i]
+		if i > startIndex && !continuesBlock(instructions[i-1], ins) {
+			return 0, false
+		}
+		// called functions can change the register as well
+		if (i != counterIndex && changesRegister(ins, register)) || ins.name == m6502.Jsr.Name {
+			return 0, false
+		}
+		// a branch out of the loop ends it early
+		if ins.addressing == m6502.RelativeAddressing &&
+			(ins.branchTarget() < instructions[startIndex].address || ins.branchTarget() > instructions[branchIndex].address) {
+
+			return 0, false
+		}
+	}
+
+	initial, ok := registerInitializer(instructions, startIndex, register)
+	if !ok {
+		return 0, false
+	}
+	return loopIterations(counter.name, compare, initial), true
+}
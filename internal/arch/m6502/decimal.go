@@ -0,0 +1,47 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const decimalModeComment = "decimal mode (ignored on NES 2A03)"
+
+// annotateDecimalMode annotates the instructions that enable and disable the decimal mode
+// and the arithmetic instructions in between. The NES CPU does not support the decimal mode,
+// its usage indicates ported code or bugs.
+func annotateDecimalMode(instructions []instructionInfo) {
+	var decimalMode bool
+
+	for i, ins := range instructions {
+		if decimalMode && i > 0 && !continuesBlock(instructions[i-1], ins) {
+			decimalMode = false
+		}
+
+		switch ins.name {
+		case m6502.Sed.Name:
+			decimalMode = true
+			ins.offsetInfo.AddComment(decimalModeComment)
+
+		case m6502.Cld.Name:
+			if decimalMode {
+				ins.offsetInfo.AddComment(decimalModeComment)
+			}
+			decimalMode = false
+
+		case m6502.Adc.Name, m6502.Sbc.Name:
+			if decimalMode {
+				ins.offsetInfo.AddComment(decimalModeComment)
+			}
+		}
+	}
+}
+
+// continuesBlock returns whether the instruction is executed after the previous instruction
+// as part of the same code block.
+func continuesBlock(previous, ins instructionInfo) bool {
+	if previous.address+uint16(len(previous.offsetInfo.Data)) != ins.address {
+		return false
+	}
+	_, ok := m6502.NotExecutingFollowingOpcodeInstructions[previous.name]
+	return !ok
+}
@@ -0,0 +1,155 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const delayLoopComment = "busy-wait / delay loop"
+
+// ramEnd is the last address of the mirrored internal RAM, reads from higher addresses can
+// access hardware registers that have side effects.
+const ramEnd = 0x1fff
+
+// registerInstructions contains the instructions that only change registers or flags.
+var registerInstructions = map[string]struct{}{
+	m6502.Clc.Name: {},
+	m6502.Cld.Name: {},
+	m6502.Clv.Name: {},
+	m6502.Dex.Name: {},
+	m6502.Dey.Name: {},
+	m6502.Inx.Name: {},
+	m6502.Iny.Name: {},
+	m6502.Nop.Name: {},
+	m6502.Sec.Name: {},
+	m6502.Sed.Name: {},
+	m6502.Tax.Name: {},
+	m6502.Tay.Name: {},
+	m6502.Txa.Name: {},
+	m6502.Tya.Name: {},
+}
+
+// shiftInstructions contains the instructions that only change the accumulator when used with
+// the accumulator addressing.
+var shiftInstructions = map[string]struct{}{
+	m6502.Asl.Name: {},
+	m6502.Lsr.Name: {},
+	m6502.Rol.Name: {},
+	m6502.Ror.Name: {},
+}
+
+// immediateInstructions contains the instructions that only change registers or flags when used
+// with an immediate value.
+var immediateInstructions = map[string]struct{}{
+	m6502.Adc.Name: {},
+	m6502.And.Name: {},
+	m6502.Cmp.Name: {},
+	m6502.Cpx.Name: {},
+	m6502.Cpy.Name: {},
+	m6502.Eor.Name: {},
+	m6502.Lda.Name: {},
+	m6502.Ldx.Name: {},
+	m6502.Ldy.Name: {},
+	m6502.Ora.Name: {},
+	m6502.Sbc.Name: {},
+}
+
+// delayLoop contains the instruction indexes of the start and the backward branch of a loop.
+type delayLoop struct {
+	start int
+	end   int
+}
+
+// detectDelayLoops detects tight loops that have no externally visible effect, their body only
+// changes registers and flags and can not be left before the loop ends. Simple loops that only count down or up a register
+// are skipped, for nested loops only the outermost loop start gets annotated.
+func detectDelayLoops(instructions []instructionInfo) {
+	var loops []delayLoop
+
+	for i, ins := range instructions {
+		if ins.addressing != m6502.RelativeAddressing || ins.branchTarget() > ins.address {
+			continue
+		}
+
+		start := instructionIndex(instructions, ins.branchTarget())
+		if start < 0 || counterLoop(instructions[start:i+1]) {
+			continue
+		}
+		if sideEffectFreeLoop(instructions[start : i+1]) {
+			loops = append(loops, delayLoop{start: start, end: i})
+		}
+	}
+
+	for i, loop := range loops {
+		if !nestedLoop(loops, i) {
+			instructions[loop.start].offsetInfo.AddComment(delayLoopComment)
+		}
+	}
+}
+
+// sideEffectFreeLoop returns whether all instructions of the loop body are directly following
+// each other, have no externally visible effect and all branches stay inside the loop.
+func sideEffectFreeLoop(body []instructionInfo) bool {
+	first, last := body[0].address, body[len(body)-1].address
+
+	for i, ins := range body {
+		if i > 0 && body[i-1].address+uint16(len(body[i-1].offsetInfo.Data)) != ins.address {
+			return false
+		}
+
+		if ins.addressing == m6502.RelativeAddressing {
+			if target := ins.branchTarget(); target < first || target > last {
+				return false // the loop can be left before it ends
+			}
+			continue
+		}
+		if !sideEffectFree(ins) {
+			return false
+		}
+	}
+	return true
+}
+
+// sideEffectFree returns whether the instruction only changes registers or flags, memory
+// reads are not accepted as the loop could be searching or waiting for a value.
+func sideEffectFree(ins instructionInfo) bool {
+	if _, ok := registerInstructions[ins.name]; ok {
+		return ins.addressing == m6502.ImpliedAddressing
+	}
+	if _, ok := shiftInstructions[ins.name]; ok {
+		return ins.addressing == m6502.AccumulatorAddressing
+	}
+	if _, ok := immediateInstructions[ins.name]; ok {
+		return ins.addressing == m6502.ImmediateAddressing
+	}
+	return false
+}
+
+// counterLoop returns whether the loop body only consists of branches and a single increment
+// or decrement of an index register.
+func counterLoop(body []instructionInfo) bool {
+	var counters int
+	for _, ins := range body {
+		switch {
+		case ins.addressing == m6502.RelativeAddressing:
+		case ins.name == m6502.Dex.Name, ins.name == m6502.Dey.Name,
+			ins.name == m6502.Inx.Name, ins.name == m6502.Iny.Name:
+			counters++
+		default:
+			return false
+		}
+	}
+	return counters == 1
+}
+
+// nestedLoop returns whether the loop at the given index is contained in a different loop.
+func nestedLoop(loops []delayLoop, index int) bool {
+	loop := loops[index]
+	for i, outer := range loops {
+		if i != index && outer.start <= loop.start && outer.end >= loop.end &&
+			(outer.start != loop.start || outer.end != loop.end) {
+
+			return true
+		}
+	}
+	return false
+}
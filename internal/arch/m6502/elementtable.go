@@ -0,0 +1,82 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const elementTableComment = "table of %d-byte elements"
+
+// elementTableMaxDistance is the maximum number of instructions that are checked after the transfer
+// of the shifted accumulator to an index register for the indexed table load.
+const elementTableMaxDistance = 4
+
+// number of left shifts of the index that are accepted as multiplication by the element size,
+// single shifts are skipped as they are the common access of pointer tables.
+const (
+	elementTableShiftsMin = 2
+	elementTableShiftsMax = 4
+)
+
+// detectElementTableAccesses detects table accesses of multi-byte elements, the index is multiplied
+// by the element size using left shifts of the accumulator and transferred to an index register
+// that is used by a following absolute indexed load. The load gets annotated with the element size.
+func detectElementTableAccesses(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if ins.name != m6502.Tax.Name && ins.name != m6502.Tay.Name {
+			continue
+		}
+
+		shifts := indexShifts(instructions, i)
+		if shifts < elementTableShiftsMin || shifts > elementTableShiftsMax {
+			continue
+		}
+
+		load := elementTableLoad(instructions, i, indexRegister(ins.name))
+		if load < 0 {
+			continue
+		}
+		instructions[load].offsetInfo.AddComment(fmt.Sprintf(elementTableComment, 1<<shifts))
+	}
+}
+
+// indexShifts returns the number of accumulator left shifts that directly precede the register
+// transfer at the given index.
+func indexShifts(instructions []instructionInfo, transfer int) int {
+	var shifts int
+	for i := transfer - 1; i >= 0 && instructions[i+1].follows(instructions[i]); i-- {
+		ins := instructions[i]
+		if ins.name != m6502.Asl.Name || ins.addressing != m6502.AccumulatorAddressing {
+			break
+		}
+		shifts++
+	}
+	return shifts
+}
+
+// elementTableLoad returns the index of the first absolute indexed load that uses the given index
+// register after the register transfer at the given index, or -1 if none was found before the
+// register gets changed.
+func elementTableLoad(instructions []instructionInfo, transfer int, register cpuRegister) int {
+	for i := transfer + 1; i < len(instructions) && i <= transfer+elementTableMaxDistance; i++ {
+		ins := instructions[i]
+		if !ins.follows(instructions[i-1]) {
+			return -1
+		}
+
+		switch ins.name {
+		case m6502.Lda.Name, m6502.Ldx.Name, m6502.Ldy.Name:
+			if (ins.addressing == m6502.AbsoluteXAddressing || ins.addressing == m6502.AbsoluteYAddressing) &&
+				indexedRegister(ins.addressing) == register {
+
+				return i
+			}
+		}
+
+		if changesRegister(ins, register) {
+			return -1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,107 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	farCallComment            = "far-call trampoline to bank %d"
+	farCallDestinationComment = "far-call destination in bank %d"
+	farCallDestinationNaming  = "_bank_%d_func_%04x"
+)
+
+// farCallMaxSize is the maximum number of instructions at the start of a function that are
+// checked for the bank switch, the call and the restore of the bank.
+const farCallMaxSize = 12
+
+// farCallInfo contains the details of a detected far call wrapper.
+type farCallInfo struct {
+	bank     uint16       // bank that is switched to before the call
+	register bankRegister // bank register that the bank is written to
+	callee   uint16       // address of the called function
+}
+
+// detectFarCalls detects wrapper functions that switch a PRG bank by writing an immediate value
+// to a PRG bank register of the mapper, call a function and restore the bank afterwards. The
+// wrapper gets annotated with the target bank. If the register switches a fixed window that
+// contains the callee, the callee is resolved into the target bank and gets labeled there.
+func detectFarCalls(dis arch.Disasm, mapper byte, instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if !ins.offsetInfo.IsType(program.CallDestination) || ins.offsetInfo.IsType(program.JumpEngine) {
+			continue
+		}
+
+		call, ok := farCall(mapper, instructions, i)
+		if !ok {
+			continue
+		}
+		ins.offsetInfo.AddLabelComment(fmt.Sprintf(farCallComment, call.bank))
+
+		// a callee in a currently mapped bank gets labeled by the followed execution flow
+		callee := farCallee(dis, call)
+		if callee != nil && callee.Label == "" && callee != dis.Mapper().OffsetInfo(call.callee) {
+			callee.Label = fmt.Sprintf(farCallDestinationNaming, call.bank, call.callee)
+			callee.AddLabelComment(fmt.Sprintf(farCallDestinationComment, call.bank))
+		}
+	}
+}
+
+// farCall returns the details of the call if the function starting at the given index is a
+// far call wrapper.
+func farCall(mapper byte, instructions []instructionInfo, start int) (farCallInfo, bool) {
+	var call farCallInfo
+	var bankSelected, called bool
+
+	for i := start; i < len(instructions) && i < start+farCallMaxSize; i++ {
+		ins := instructions[i]
+		if i > start && !continuesBlock(instructions[i-1], ins) {
+			return farCallInfo{}, false
+		}
+
+		switch {
+		case isPRGBankWrite(mapper, ins):
+			if called {
+				return call, true // the bank gets restored after the call
+			}
+			call.bank, bankSelected = immediateStoreValue(instructions, i)
+			call.register, _ = prgBankRegister(mapper, ins.operand())
+
+		case ins.name == m6502.Jsr.Name:
+			if !bankSelected || called {
+				return farCallInfo{}, false
+			}
+			call.callee = ins.operand()
+			called = true
+
+		case ins.name == m6502.Rts.Name || ins.name == m6502.Rti.Name || ins.name == m6502.Jmp.Name:
+			return farCallInfo{}, false
+		}
+	}
+	return farCallInfo{}, false
+}
+
+// farCallee returns the offset information of the callee in the target bank of the far call.
+// It returns nil if the bank register does not switch a fixed window that contains the callee
+// or if the bank does not exist in the PRG ROM.
+func farCallee(dis arch.Disasm, call farCallInfo) *arch.Offset {
+	register := call.register
+	if register.windowSize == 0 || call.callee < register.window ||
+		int(call.callee) >= int(register.window)+register.windowSize {
+
+		return nil
+	}
+
+	offset := int(call.bank)*register.windowSize + int(call.callee-register.window)
+	return dis.Mapper().PRGOffsetInfo(offset)
+}
+
+// isPRGBankWrite returns whether the instruction writes a register to a PRG bank register of the
+// given mapper.
+func isPRGBankWrite(mapper byte, ins instructionInfo) bool {
+	_, ok := storeLoads[ins.name]
+	return ok && ins.addressing == m6502.AbsoluteAddressing && isPRGBankRegister(mapper, ins.operand())
+}
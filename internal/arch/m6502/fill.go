@@ -0,0 +1,126 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	fillBufferComment = "fill buffer with $%02X, size %d"
+	fillBufferNaming  = "ram_buffer_%04x"
+)
+
+// detectRAMFillLoops detects counted loops that fill RAM structures with a constant value by
+// indexed stores of an immediate loaded register. Every filled structure is named as buffer that
+// covers all filled bytes and every store is annotated with the fill value and number of bytes.
+func detectRAMFillLoops(dis arch.Disasm, instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if ins.name != m6502.Bne.Name || ins.branchTarget() >= ins.address {
+			continue
+		}
+		iterations, ok := countedLoopIterations(instructions, i)
+		if !ok {
+			continue
+		}
+
+		startIndex := instructionIndex(instructions, ins.branchTarget())
+		stores, value, ok := fillLoopStores(instructions, startIndex, i)
+		if !ok {
+			continue
+		}
+
+		// calculate the lowest index of the filled bytes
+		counter := instructions[stores[len(stores)-1]+1]
+		firstIndex, _ := registerInitializer(instructions, startIndex, indexRegister(counter.name))
+		switch {
+		case iterations == 0x100:
+			firstIndex = 0
+		case counter.name == m6502.Dex.Name || counter.name == m6502.Dey.Name:
+			firstIndex = firstIndex - iterations + 1
+		}
+		if firstIndex < 0 || firstIndex+iterations > 0x100 {
+			continue
+		}
+
+		// the buffer starts at the referenced base address to name all accesses of the loop
+		for _, storeIndex := range stores {
+			store := instructions[storeIndex]
+			address := store.operand()
+			dis.Variables().AddBuffer(address, firstIndex+iterations, fmt.Sprintf(fillBufferNaming, address))
+			store.offsetInfo.AddComment(fmt.Sprintf(fillBufferComment, value, iterations))
+		}
+	}
+}
+
+// fillLoopStores returns the indexes of the stores of the fill loop between the loop start and the
+// branch back and the stored value. The loop has to consist of an optional immediate load of the
+// stored register, the indexed stores into RAM and the change and optional compare of the counter
+// register. Without a load inside of the loop, the stored register has to be loaded directly
+// before the loop.
+func fillLoopStores(instructions []instructionInfo, startIndex, branchIndex int) ([]int, uint16, bool) {
+	index := startIndex
+	var value uint16
+	var loaded bool
+	if ins := instructions[index]; ins.addressing == m6502.ImmediateAddressing &&
+		(ins.name == m6502.Lda.Name || ins.name == m6502.Ldx.Name || ins.name == m6502.Ldy.Name) {
+
+		value = ins.operand()
+		loaded = true
+		index++
+	}
+
+	var stores []int
+	for ; index < branchIndex; index++ {
+		ins := instructions[index]
+		if _, ok := storeLoads[ins.name]; !ok || indexedRegister(ins.addressing) == noRegister ||
+			ins.addressing == m6502.IndirectXAddressing || ins.addressing == m6502.IndirectYAddressing ||
+			ins.operand() > ramEnd {
+
+			break
+		}
+		if len(stores) > 0 && ins.name != instructions[stores[0]].name {
+			return nil, 0, false
+		}
+		stores = append(stores, index)
+	}
+	if len(stores) == 0 {
+		return nil, 0, false
+	}
+
+	// the remaining instructions have to be the counter change and compare
+	counter := instructions[index]
+	if indexedRegister(instructions[stores[0]].addressing) != indexRegister(counter.name) ||
+		index+1 != branchIndex && index+2 != branchIndex {
+
+		return nil, 0, false
+	}
+
+	loadName := storeLoads[instructions[stores[0]].name]
+	if loaded {
+		return stores, value, instructions[startIndex].name == loadName
+	}
+	value, ok := fillValueBeforeLoop(instructions, startIndex, loadName)
+	return stores, value, ok
+}
+
+// fillValueBeforeLoop returns the immediate value that the stored register gets loaded with in the
+// straight-line code before the loop, only the initialization of the counter register is allowed
+// between the load and the loop start.
+func fillValueBeforeLoop(instructions []instructionInfo, startIndex int, loadName string) (uint16, bool) {
+	for i := startIndex - 1; i >= 0 && i >= startIndex-2; i-- {
+		ins := instructions[i]
+		next := instructions[i+1]
+		if ins.address+uint16(len(ins.offsetInfo.Data)) != next.address || (i+1 != startIndex && !next.follows(ins)) {
+			return 0, false
+		}
+		if ins.addressing != m6502.ImmediateAddressing {
+			return 0, false
+		}
+		if ins.name == loadName {
+			return ins.operand(), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	frameFlagName        = "frame_ready_flag"
+	frameFlagWaitComment = "wait for NMI frame flag"
+)
+
+// frameFlagWriters contains the instructions that can set a flag in RAM.
+var frameFlagWriters = map[string]struct{}{
+	m6502.Sta.Name: {},
+	m6502.Stx.Name: {},
+	m6502.Sty.Name: {},
+	m6502.Inc.Name: {},
+	m6502.Dec.Name: {},
+}
+
+// frameFlagPolls contains the instructions that can poll a flag in RAM.
+var frameFlagPolls = map[string]struct{}{
+	m6502.Lda.Name: {},
+	m6502.Ldx.Name: {},
+	m6502.Ldy.Name: {},
+	m6502.Bit.Name: {},
+}
+
+// detectFrameFlagWaits detects the frame synchronization of the main code, where the NMI handler
+// sets a flag in RAM and the main code spins in a loop polling the flag. The flag variable gets
+// named and the poll loop annotated.
+func detectFrameFlagWaits(dis arch.Disasm, instructions []instructionInfo) {
+	nmi, err := dis.ReadMemoryWord(m6502.NMIAddress)
+	if err != nil || nmi == 0 {
+		return
+	}
+	written := nmiRAMWrites(instructions, nmi)
+	if len(written) == 0 {
+		return
+	}
+
+	var flag uint16
+	for i, ins := range instructions {
+		if ins.offsetInfo.Context == nmi || !isPollLoop(instructions, i) {
+			continue
+		}
+		address := ins.operand()
+		if _, ok := written[address]; !ok || (flag != 0 && address != flag) {
+			continue
+		}
+
+		if flag == 0 {
+			flag = address
+			dis.Variables().AddBuffer(flag, 1, frameFlagName)
+		}
+		ins.offsetInfo.AddComment(frameFlagWaitComment)
+	}
+}
+
+// nmiRAMWrites returns all RAM addresses that are written by the NMI handler.
+func nmiRAMWrites(instructions []instructionInfo, nmi uint16) map[uint16]struct{} {
+	written := map[uint16]struct{}{}
+	for _, ins := range instructions {
+		if ins.offsetInfo.Context != nmi || !isRAMAccess(ins) {
+			continue
+		}
+		if _, ok := frameFlagWriters[ins.name]; ok {
+			written[ins.operand()] = struct{}{}
+		}
+	}
+	return written
+}
+
+// isPollLoop returns whether the instruction at the given index reads a RAM address and is
+// directly followed by a branch back to the read.
+func isPollLoop(instructions []instructionInfo, index int) bool {
+	ins := instructions[index]
+	if _, ok := frameFlagPolls[ins.name]; !ok || !isRAMAccess(ins) || index+1 >= len(instructions) {
+		return false
+	}
+
+	branch := instructions[index+1]
+	return branch.addressing == m6502.RelativeAddressing && continuesBlock(ins, branch) &&
+		branch.branchTarget() == ins.address
+}
+
+// isRAMAccess returns whether the instruction directly accesses an address of the internal RAM.
+func isRAMAccess(ins instructionInfo) bool {
+	switch ins.addressing {
+	case m6502.ZeroPageAddressing, m6502.AbsoluteAddressing:
+		return ins.operand() <= ramEnd
+	default:
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	wordIncrementComment = "increment 16-bit value at $%0*X"
+	wordNaming           = "_word_%04x"
+)
+
+// detectWordIncrements detects the increment of a 16-bit value in RAM that is implemented as an
+// increment of the low byte, a branch over the increment of the high byte if the low byte did not
+// overflow and the increment of the high byte. The low byte increment gets annotated and the
+// value gets named as 16-bit variable.
+func detectWordIncrements(dis arch.Disasm, instructions []instructionInfo) {
+	for i := 0; i+2 < len(instructions); i++ {
+		low, branch, high := instructions[i], instructions[i+1], instructions[i+2]
+		if !isRAMIncrement(low) || branch.name != m6502.Bne.Name || !isRAMIncrement(high) ||
+			high.addressing != low.addressing || high.operand() != low.operand()+1 {
+
+			continue
+		}
+		if !branch.follows(low) || !high.follows(branch) ||
+			branch.branchTarget() != high.address+uint16(len(high.offsetInfo.Data)) {
+
+			continue
+		}
+
+		address := low.operand()
+		digits := 4
+		if low.addressing == m6502.ZeroPageAddressing {
+			digits = 2
+		}
+		low.offsetInfo.AddComment(fmt.Sprintf(wordIncrementComment, digits, address))
+		dis.Variables().AddBuffer(address, 2, fmt.Sprintf(wordNaming, address))
+		i += 2
+	}
+}
+
+// isRAMIncrement returns whether the instruction increments a directly addressed byte in RAM.
+func isRAMIncrement(ins instructionInfo) bool {
+	if ins.name != m6502.Inc.Name {
+		return false
+	}
+	switch ins.addressing {
+	case m6502.ZeroPageAddressing:
+		return true
+	case m6502.AbsoluteAddressing:
+		return ins.operand() <= ramEnd
+	default:
+		return false
+	}
+}
@@ -12,6 +12,13 @@ type Instruction struct {
 	ins *m6502.Instruction
 }
 
+// ContinuesExecution returns true if the execution continues with the following instruction,
+// calls are expected to return.
+func (i Instruction) ContinuesExecution() bool {
+	_, ok := m6502.NotExecutingFollowingOpcodeInstructions[i.ins.Name]
+	return !ok
+}
+
 // IsCall returns true if the instruction is a call.
 func (i Instruction) IsCall() bool {
 	return i.ins.Name == m6502.Jsr.Name
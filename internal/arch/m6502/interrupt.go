@@ -0,0 +1,97 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	saveRegistersComment    = "save registers"
+	restoreRegistersComment = "restore registers"
+)
+
+// registerSaveInstructions contains the instructions that are part of saving the registers on
+// the stack at the start of an interrupt handler.
+var registerSaveInstructions = map[string]struct{}{
+	m6502.Pha.Name: {},
+	m6502.Php.Name: {},
+	m6502.Txa.Name: {},
+	m6502.Tya.Name: {},
+}
+
+// registerRestoreInstructions contains the instructions that are part of restoring the registers
+// from the stack at the end of an interrupt handler.
+var registerRestoreInstructions = map[string]struct{}{
+	m6502.Pla.Name: {},
+	m6502.Plp.Name: {},
+	m6502.Tax.Name: {},
+	m6502.Tay.Name: {},
+}
+
+// detectInterruptRegisterSaves detects the saving of the registers on the stack at the start of the
+// NMI and IRQ handlers and the restoring of the registers before every rti of the handlers. The first
+// instruction of the prologue and of every epilogue is annotated.
+func detectInterruptRegisterSaves(dis arch.Disasm, instructions []instructionInfo) {
+	reset, err := resetAddress(dis)
+	if err != nil {
+		return
+	}
+
+	for _, vector := range []uint16{m6502.NMIAddress, m6502.IrqAddress} {
+		handler, err := dis.ReadMemoryWord(vector)
+		if err != nil || handler == 0 || handler == reset {
+			continue
+		}
+		start := instructionIndex(instructions, handler)
+		if start < 0 || !savesRegisters(instructions, start) {
+			continue
+		}
+
+		instructions[start].offsetInfo.AddComment(saveRegistersComment)
+		annotateRegisterRestores(instructions, handler)
+	}
+}
+
+// savesRegisters returns whether the handler starting at the given index pushes at least one
+// register as part of its first instructions.
+func savesRegisters(instructions []instructionInfo, start int) bool {
+	for i := start; i < len(instructions); i++ {
+		ins := instructions[i]
+		if i > start && !continuesBlock(instructions[i-1], ins) {
+			return false
+		}
+		if _, ok := registerSaveInstructions[ins.name]; !ok {
+			return false
+		}
+		if ins.name == m6502.Pha.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateRegisterRestores annotates the first instruction of the register restoring sequence
+// before every rti of the handler.
+func annotateRegisterRestores(instructions []instructionInfo, handler uint16) {
+	for i, ins := range instructions {
+		if ins.name != m6502.Rti.Name || ins.offsetInfo.Context != handler {
+			continue
+		}
+
+		start := i
+		var pulled bool
+		for j := i - 1; j >= 0 && instructions[j+1].follows(instructions[j]); j-- {
+			if _, ok := registerRestoreInstructions[instructions[j].name]; !ok {
+				break
+			}
+			if instructions[j].name == m6502.Pla.Name {
+				pulled = true
+			}
+			start = j
+		}
+
+		if pulled {
+			instructions[start].offsetInfo.AddComment(restoreRegistersComment)
+		}
+	}
+}
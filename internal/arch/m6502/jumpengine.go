@@ -32,7 +32,7 @@ func (ar *Arch6502) checkForJumpEngineJmp(dis arch.Disasm, jumpAddress uint16, o
 	}
 
 	if len(dataReferences) > 1 {
-		jumpEngine.GetFunctionTableReference(offsetInfo.Context, dataReferences)
+		jumpEngine.GetFunctionTableReference(offsetInfo.Context, dataReferences, tableBoundsCheck(contextOffsets))
 	}
 
 	dis.Logger().Debug("Jump engine detected",
@@ -75,3 +75,87 @@ func (ar *Arch6502) checkForJumpEngineCall(dis arch.Disasm, address uint16, offs
 	}
 	return nil
 }
+
+// tableBoundsCheck returns the number of jump table entries if the index is bounds checked by
+// a compare with an immediate value and a branch on carry set before the table access. The
+// compared register has to be the index of the following table access.
+// It returns 0 if no bounds check was found.
+func tableBoundsCheck(offsets []*arch.Offset) int {
+	var entries int
+
+	for i := 0; i+1 < len(offsets); i++ {
+		compare := offsets[i]
+		if compare.Opcode == nil || m6502.AddressingMode(compare.Opcode.Addressing()) != m6502.ImmediateAddressing ||
+			len(compare.Data) != 2 {
+
+			continue
+		}
+		register, ok := comparedRegisters[compare.Opcode.Instruction().Name()]
+		if !ok {
+			continue
+		}
+
+		branch := offsets[i+1]
+		if branch.Opcode != nil && branch.Opcode.Instruction().Name() == m6502.Bcs.Name &&
+			isTableIndex(offsets[i+2:], register) {
+
+			entries = int(compare.Data[1])
+		}
+	}
+	return entries
+}
+
+// comparedRegisters maps the compare instructions to the register that they compare.
+var comparedRegisters = map[string]cpuRegister{
+	m6502.Cmp.Name: registerA,
+	m6502.Cpx.Name: registerX,
+	m6502.Cpy.Name: registerY,
+}
+
+// registerTransfers maps the register transfer instructions to their source and destination register.
+var registerTransfers = map[string][2]cpuRegister{
+	m6502.Tax.Name: {registerA, registerX},
+	m6502.Tay.Name: {registerA, registerY},
+	m6502.Txa.Name: {registerX, registerA},
+	m6502.Tya.Name: {registerY, registerA},
+}
+
+// registerLoads maps the instructions that load a new value into a register to the loaded registers.
+// Arithmetic instructions keep the index in the register, as they scale or offset it to the entry.
+var registerLoads = map[string][]cpuRegister{
+	m6502.Lax.Name: {registerA, registerX},
+	m6502.Lda.Name: {registerA},
+	m6502.Ldx.Name: {registerX},
+	m6502.Ldy.Name: {registerY},
+	m6502.Pla.Name: {registerA},
+	m6502.Tsx.Name: {registerX},
+}
+
+// isTableIndex returns whether the value of the given register is the index register of the
+// first absolute indexed load of the offsets, directly or after being transferred between registers.
+func isTableIndex(offsets []*arch.Offset, register cpuRegister) bool {
+	holdsIndex := map[cpuRegister]bool{register: true}
+
+	for _, offset := range offsets {
+		if offset.Opcode == nil {
+			return false
+		}
+		name := offset.Opcode.Instruction().Name()
+		addressing := m6502.AddressingMode(offset.Opcode.Addressing())
+
+		if addressing == m6502.AbsoluteXAddressing || addressing == m6502.AbsoluteYAddressing {
+			if _, ok := registerLoads[name]; ok {
+				return holdsIndex[indexedRegister(addressing)]
+			}
+		}
+
+		if transfer, ok := registerTransfers[name]; ok {
+			holdsIndex[transfer[1]] = holdsIndex[transfer[0]]
+			continue
+		}
+		for _, loaded := range registerLoads[name] {
+			holdsIndex[loaded] = false
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// registerInitializerMaxDistance is the maximum number of instructions that are checked before a loop
+// start to find the immediate initialization of the loop counter register.
+const registerInitializerMaxDistance = 8
+
+// cpuRegister defines a register of the CPU.
+type cpuRegister int
+
+const (
+	noRegister cpuRegister = iota
+	registerX
+	registerY
+	registerA
+)
+
+// indexRegister returns the index register that is used by the given instruction.
+func indexRegister(name string) cpuRegister {
+	switch name {
+	case m6502.Inx.Name, m6502.Dex.Name, m6502.Cpx.Name, m6502.Ldx.Name, m6502.Tax.Name, m6502.Tsx.Name, m6502.Stx.Name:
+		return registerX
+	case m6502.Iny.Name, m6502.Dey.Name, m6502.Cpy.Name, m6502.Ldy.Name, m6502.Tay.Name, m6502.Sty.Name:
+		return registerY
+	default:
+		return noRegister
+	}
+}
+
+// indexedRegister returns the index register that is used by the addressing mode.
+func indexedRegister(addressing m6502.AddressingMode) cpuRegister {
+	switch addressing {
+	case m6502.AbsoluteXAddressing, m6502.ZeroPageXAddressing, m6502.IndirectXAddressing:
+		return registerX
+	case m6502.AbsoluteYAddressing, m6502.ZeroPageYAddressing, m6502.IndirectYAddressing:
+		return registerY
+	default:
+		return noRegister
+	}
+}
+
+// changesRegister returns whether the instruction changes the value of the given index register.
+func changesRegister(ins instructionInfo, register cpuRegister) bool {
+	switch ins.name {
+	case m6502.Cpx.Name, m6502.Cpy.Name, m6502.Stx.Name, m6502.Sty.Name:
+		return false
+	case m6502.Lax.Name:
+		return register == registerX
+	default:
+		return indexRegister(ins.name) == register
+	}
+}
+
+// registerInitializer searches backwards from the loop start for the immediate value that the index
+// register gets initialized with. It returns false if the register is not initialized by an immediate
+// value in the straight-line code before the loop.
+func registerInitializer(instructions []instructionInfo, loopStartIndex int, register cpuRegister) (int, bool) {
+	for i := loopStartIndex - 1; i >= 0 && i >= loopStartIndex-registerInitializerMaxDistance; i-- {
+		ins := instructions[i]
+		next := instructions[i+1]
+		if ins.address+uint16(len(ins.offsetInfo.Data)) != next.address {
+			return 0, false
+		}
+		if i+1 != loopStartIndex && !next.follows(ins) {
+			return 0, false
+		}
+
+		if !changesRegister(ins, register) {
+			continue
+		}
+		if ins.addressing != m6502.ImmediateAddressing {
+			return 0, false
+		}
+		return int(ins.operand()), true
+	}
+	return 0, false
+}
+
+// loopIterations returns the number of iterations of a counted loop based on the initial value of
+// the counter register, the instruction changing the counter and an optional compare instruction.
+// Without a compare instruction the loop ends when the counter reaches zero.
+func loopIterations(counterName string, compare *instructionInfo, initial int) int {
+	var end int
+	if compare != nil {
+		end = int(compare.operand())
+	}
+
+	var iterations int
+	switch counterName {
+	case m6502.Inx.Name, m6502.Iny.Name:
+		iterations = (end - initial) & 0xff
+	default:
+		iterations = (initial - end) & 0xff
+	}
+
+	if iterations == 0 {
+		return 0x100
+	}
+	return iterations
+}
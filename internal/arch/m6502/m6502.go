@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/assembler"
+	"github.com/retroenv/nesgodisasm/internal/options"
 	"github.com/retroenv/retrogolib/arch/cpu/m6502"
 	"github.com/retroenv/retrogolib/arch/nes/cartridge"
 	"github.com/retroenv/retrogolib/arch/nes/parameter"
@@ -13,15 +15,17 @@ import (
 
 var _ arch.Architecture = &Arch6502{}
 
-// New returns a new 6502 architecture configuration.
-func New(converter parameter.Converter) *Arch6502 {
+// New returns a new 6502 architecture configuration that formats operands in the given syntax.
+func New(syntax assembler.Syntax) *Arch6502 {
 	return &Arch6502{
-		converter: converter,
+		converter: syntax.Converter(),
+		syntax:    syntax,
 	}
 }
 
 type Arch6502 struct {
 	converter parameter.Converter
+	syntax    assembler.Syntax
 }
 
 // LastCodeAddress returns the last possible address of code.
@@ -59,18 +63,43 @@ func (ar *Arch6502) ProcessOffset(dis arch.Disasm, address uint16, offsetInfo *a
 		offsetInfo.Code = fmt.Sprintf("%s %s", name, params)
 	}
 
+	opts := dis.Options()
+	if opts.AnnotateAddressing {
+		offsetInfo.AddComment(addressingNames[m6502.AddressingMode(op.Addressing())])
+	}
+	if opts.AnnotateIndexed {
+		base := fmt.Sprintf("$%04X", operandWord(offsetInfo.Data))
+		if comment, ok := indexedBaseComment(m6502.AddressingMode(op.Addressing()), base); ok {
+			offsetInfo.AddComment(comment)
+		}
+	}
+	if opts.BranchOffsetComments && op.Addressing() == int(m6502.RelativeAddressing) && len(offsetInfo.Data) == 2 {
+		offsetInfo.AddComment(fmt.Sprintf("%+d", int8(offsetInfo.Data[1])))
+	}
+
 	if _, ok := m6502.NotExecutingFollowingOpcodeInstructions[name]; ok {
 		if err := ar.checkForJumpEngineJmp(dis, pc, offsetInfo); err != nil {
 			return false, err
 		}
+		if err := ar.checkForJumpTable(dis, pc, offsetInfo); err != nil {
+			return false, err
+		}
 	} else {
 		opcodeLength := uint16(len(offsetInfo.Data))
 		followingOpcodeAddress := pc + opcodeLength
-		dis.AddAddressToParse(followingOpcodeAddress, offsetInfo.Context, address, instruction, false)
+		if opts.Unreachable == options.UnreachableData && isComplementaryBranch(dis, address, offsetInfo) {
+			// the code following a complementary branch pair is not reached by the branches, in data
+			// mode it only gets parsed if the second branch is a branch destination or it is referenced
+			// by other code.
+			dis.AddUnreachableAddressToParse(followingOpcodeAddress, offsetInfo.Context, address, instruction)
+		} else {
+			dis.AddAddressToParse(followingOpcodeAddress, offsetInfo.Context, address, instruction, false)
+		}
 		if err := ar.checkForJumpEngineCall(dis, pc, offsetInfo); err != nil {
 			return false, err
 		}
 	}
+	checkForRTSTrampoline(dis, pc, offsetInfo)
 
 	return true, nil
 }
@@ -0,0 +1,50 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const functionMetricsComment = "%d instrs, %d branches"
+
+// functionMetrics contains the complexity metric of a function.
+type functionMetrics struct {
+	instructions int
+	branches     int
+}
+
+// annotateFunctionMetrics annotates the label of every function with the number of instructions
+// and relative branches of all instructions that are part of the function context.
+func annotateFunctionMetrics(instructions []instructionInfo) {
+	var contexts []uint16
+	metrics := map[uint16]*functionMetrics{}
+
+	for _, ins := range instructions {
+		context := ins.offsetInfo.Context
+		if context == 0 {
+			continue
+		}
+
+		metric, ok := metrics[context]
+		if !ok {
+			metric = &functionMetrics{}
+			metrics[context] = metric
+			contexts = append(contexts, context)
+		}
+		metric.instructions++
+		if ins.addressing == m6502.RelativeAddressing {
+			metric.branches++
+		}
+	}
+
+	for _, context := range contexts {
+		index := instructionIndex(instructions, context)
+		if index < 0 {
+			continue
+		}
+		metric := metrics[context]
+		instructions[index].offsetInfo.AddLabelComment(
+			fmt.Sprintf(functionMetricsComment, metric.instructions, metric.branches))
+	}
+}
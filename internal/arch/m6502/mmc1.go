@@ -0,0 +1,65 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// mmc1SerialWrites is the number of writes that are needed to load a MMC1 register.
+const mmc1SerialWrites = 5
+
+// mmc1RegisterNames maps the address window of a MMC1 register to its name,
+// the register is selected by bits 13 and 14 of the address.
+var mmc1RegisterNames = [4]string{
+	"control",
+	"CHR bank 0",
+	"CHR bank 1",
+	"PRG bank",
+}
+
+// detectMMC1Writes detects the MMC1 serial register load idiom, which consists of 5 writes
+// to the mapper register address with a right shift of the accumulator in between.
+func detectMMC1Writes(instructions []instructionInfo) {
+	for i := 0; i+2*mmc1SerialWrites-1 <= len(instructions); i++ {
+		register, ok := mmc1SerialLoad(instructions[i : i+2*mmc1SerialWrites-1])
+		if !ok {
+			continue
+		}
+
+		instructions[i].offsetInfo.AddComment("MMC1 register write: " + mmc1RegisterNames[register])
+		i += 2*mmc1SerialWrites - 2
+	}
+}
+
+// mmc1SerialLoad checks whether the instructions form a MMC1 serial load sequence and
+// returns the index of the written register.
+func mmc1SerialLoad(instructions []instructionInfo) (int, bool) {
+	var register uint16
+
+	for i, ins := range instructions {
+		if i > 0 && !ins.follows(instructions[i-1]) {
+			return 0, false
+		}
+
+		if i%2 == 1 {
+			if ins.name != m6502.Lsr.Name || ins.addressing != m6502.AccumulatorAddressing {
+				return 0, false
+			}
+			continue
+		}
+
+		if ins.name != m6502.Sta.Name || ins.addressing != m6502.AbsoluteAddressing {
+			return 0, false
+		}
+		address := ins.operand()
+		if address < 0x8000 {
+			return 0, false
+		}
+		if i == 0 {
+			register = address
+		} else if address>>13 != register>>13 {
+			return 0, false
+		}
+	}
+
+	return int(register>>13) & 3, true
+}
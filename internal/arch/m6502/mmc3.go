@@ -0,0 +1,28 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// mmc3IRQRegisterNames maps the MMC3 IRQ registers to their name, the register is selected
+// by bit 13 and the lowest bit of the address.
+var mmc3IRQRegisterNames = [2][2]string{
+	{"MMC3 IRQ latch", "MMC3 IRQ reload"},
+	{"MMC3 IRQ disable", "MMC3 IRQ enable"},
+}
+
+// annotateMMC3IRQWrites annotates all writes to the MMC3 scanline IRQ registers
+// that are mapped to $C000-$FFFF.
+func annotateMMC3IRQWrites(instructions []instructionInfo) {
+	for _, ins := range instructions {
+		if _, ok := storeLoads[ins.name]; !ok || ins.addressing != m6502.AbsoluteAddressing {
+			continue
+		}
+
+		address := ins.operand()
+		if address < 0xc000 {
+			continue
+		}
+		ins.offsetInfo.AddComment(mmc3IRQRegisterNames[address>>13&1][address&1])
+	}
+}
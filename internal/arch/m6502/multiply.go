@@ -0,0 +1,78 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// detectMultiplications detects multiplications of the accumulator by a constant that are
+// implemented as a chain of left shifts and additions of the original value. The original
+// value has to be saved to memory at the beginning of the chain, the last instruction of
+// the chain gets annotated with the computed constant.
+func detectMultiplications(instructions []instructionInfo) {
+	for i := 0; i < len(instructions); i++ {
+		end, factor, ok := multiplicationChain(instructions, i)
+		if !ok {
+			continue
+		}
+
+		instructions[end].offsetInfo.AddComment(fmt.Sprintf("A * %d", factor))
+		i = end
+	}
+}
+
+// multiplicationChain returns the index of the last instruction of a shift and add chain that
+// starts with storing the accumulator at the given index and the factor that it multiplies by.
+func multiplicationChain(instructions []instructionInfo, start int) (int, int, bool) {
+	store := instructions[start]
+	if store.name != m6502.Sta.Name ||
+		(store.addressing != m6502.ZeroPageAddressing && store.addressing != m6502.AbsoluteAddressing) {
+
+		return 0, 0, false
+	}
+
+	factor, storedFactor := 1, 1
+	end := start
+	var shifted, added bool
+
+	for i := start + 1; i < len(instructions) && instructions[i].follows(instructions[i-1]); i++ {
+		ins := instructions[i]
+		switch {
+		case ins.name == m6502.Asl.Name && ins.addressing == m6502.AccumulatorAddressing:
+			factor *= 2
+			shifted = true
+
+		case ins.name == m6502.Clc.Name && i+1 < len(instructions) &&
+			instructions[i+1].follows(ins) && isSameOperandAccess(instructions[i+1], store, m6502.Adc.Name):
+
+			i++ // skip the addition
+			factor += storedFactor
+			added = true
+
+		case isSameOperandAccess(ins, store, m6502.Sta.Name):
+			storedFactor = factor
+
+		default:
+			return checkMultiplicationChain(end, factor, shifted, added)
+		}
+		end = i
+	}
+
+	return checkMultiplicationChain(end, factor, shifted, added)
+}
+
+// checkMultiplicationChain returns the result of a multiplication chain detection, a chain is only
+// valid if it contains shifts and additions.
+func checkMultiplicationChain(end, factor int, shifted, added bool) (int, int, bool) {
+	if !shifted || !added {
+		return 0, 0, false
+	}
+	return end, factor, true
+}
+
+// isSameOperandAccess returns whether the instruction has the given name and accesses the same
+// memory address as the reference instruction.
+func isSameOperandAccess(ins, reference instructionInfo, name string) bool {
+	return ins.name == name && ins.addressing == reference.addressing && ins.operand() == reference.operand()
+}
@@ -0,0 +1,97 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const negateComment = "negate A (two's complement)"
+
+// detectNegations detects the negation of the accumulator by inverting all bits and adding one,
+// or by subtracting the stored value from zero. The last instruction of the idiom gets annotated.
+func detectNegations(instructions []instructionInfo) {
+	for i := range instructions {
+		if end, ok := complementNegation(instructions, i); ok {
+			instructions[end].offsetInfo.AddComment(negateComment)
+			continue
+		}
+		if end, ok := subtractionNegation(instructions, i); ok {
+			instructions[end].offsetInfo.AddComment(negateComment)
+		}
+	}
+}
+
+// complementNegation returns the index of the addition of a negation that starts at the given
+// index in the form of eor #$ff and an addition of one, which can be done by adding one with
+// the carry cleared or adding zero with the carry set. The carry flag instruction can also
+// precede the eor.
+func complementNegation(instructions []instructionInfo, start int) (int, bool) {
+	end := start + 2
+	if !isStraightLine(instructions, start, end) {
+		return 0, false
+	}
+
+	first, second, addition := instructions[start], instructions[start+1], instructions[end]
+	var carry string
+	switch {
+	case isImmediateValue(first, m6502.Eor.Name, 0xff):
+		carry = second.name
+	case isImmediateValue(second, m6502.Eor.Name, 0xff):
+		carry = first.name
+	default:
+		return 0, false
+	}
+
+	switch carry {
+	case m6502.Clc.Name:
+		return end, isImmediateValue(addition, m6502.Adc.Name, 1)
+	case m6502.Sec.Name:
+		return end, isImmediateValue(addition, m6502.Adc.Name, 0)
+	default:
+		return 0, false
+	}
+}
+
+// subtractionNegation returns the index of the subtraction of a negation that starts at the given
+// index with storing the accumulator, followed by loading zero, setting the carry in any order and
+// subtracting the stored value.
+func subtractionNegation(instructions []instructionInfo, start int) (int, bool) {
+	end := start + 3
+	if !isStraightLine(instructions, start, end) {
+		return 0, false
+	}
+
+	store := instructions[start]
+	if store.name != m6502.Sta.Name ||
+		(store.addressing != m6502.ZeroPageAddressing && store.addressing != m6502.AbsoluteAddressing) {
+
+		return 0, false
+	}
+
+	first, second := instructions[start+1], instructions[start+2]
+	if !(isImmediateValue(first, m6502.Lda.Name, 0) && second.name == m6502.Sec.Name) &&
+		!(first.name == m6502.Sec.Name && isImmediateValue(second, m6502.Lda.Name, 0)) {
+
+		return 0, false
+	}
+	return end, isSameOperandAccess(instructions[end], store, m6502.Sbc.Name)
+}
+
+// isStraightLine returns whether all instructions from the start to the end index exist and
+// follow each other without being a branch destination.
+func isStraightLine(instructions []instructionInfo, start, end int) bool {
+	if end >= len(instructions) {
+		return false
+	}
+	for i := start + 1; i <= end; i++ {
+		if !instructions[i].follows(instructions[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isImmediateValue returns whether the instruction has the given name and an immediate operand
+// of the given value.
+func isImmediateValue(ins instructionInfo, name string, value uint16) bool {
+	return ins.name == name && ins.addressing == m6502.ImmediateAddressing && ins.operand() == value
+}
@@ -0,0 +1,51 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const nopSledComment = "%d-cycle delay"
+
+// nopSledMinSize is the minimum number of consecutive nop instructions that are considered to be
+// used for cycle timing.
+const nopSledMinSize = 2
+
+// detectNOPSleds annotates the first instruction of every run of consecutive nop instructions with
+// the total number of cycles that the run delays the execution. A run ends at a branch destination
+// as jumping into the run results in a shorter delay.
+func detectNOPSleds(instructions []instructionInfo) {
+	for i := 0; i < len(instructions); i++ {
+		if instructions[i].name != m6502.Nop.Name {
+			continue
+		}
+
+		cycles := instructionCycles(instructions[i])
+		end := i + 1
+		for ; end < len(instructions); end++ {
+			ins := instructions[end]
+			if ins.name != m6502.Nop.Name || !continuesBlock(instructions[end-1], ins) ||
+				len(ins.offsetInfo.BranchFrom) > 0 {
+
+				break
+			}
+			cycles += instructionCycles(ins)
+		}
+
+		if end-i >= nopSledMinSize {
+			instructions[i].offsetInfo.AddComment(fmt.Sprintf(nopSledComment, cycles))
+		}
+		i = end - 1
+	}
+}
+
+// instructionCycles returns the number of cycles that the instruction takes to execute, not
+// including additional cycles for page crossings or taken branches.
+func instructionCycles(ins instructionInfo) int {
+	opcode, ok := ins.offsetInfo.Opcode.(*Opcode)
+	if !ok {
+		return 0
+	}
+	return int(opcode.op.Timing)
+}
@@ -0,0 +1,108 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+	"github.com/retroenv/retrogolib/arch/nes/register"
+)
+
+const (
+	oamBufferName = "oam_buffer"
+	oamBufferSize = 0x100
+
+	oamAddressResetComment   = "reset OAMADDR before DMA"
+	oamAddressMissingComment = "OAM DMA without OAMADDR reset"
+)
+
+// oamAddressResetMaxDistance is the maximum number of instructions that are checked before an
+// OAM DMA for the reset of the OAM address register.
+const oamAddressResetMaxDistance = 8
+
+// storeLoads maps the store instructions to the load instruction that uses the same register.
+var storeLoads = map[string]string{
+	m6502.Sta.Name: m6502.Lda.Name,
+	m6502.Stx.Name: m6502.Ldx.Name,
+	m6502.Sty.Name: m6502.Ldy.Name,
+}
+
+// detectOAMBuffer detects the OAM shadow buffer page that gets copied to the PPU by writing
+// the page to the OAM DMA register. The buffer is only named if it also gets written to
+// using indexed addressing.
+func detectOAMBuffer(dis arch.Disasm, instructions []instructionInfo) {
+	page, ok := oamDMAPage(instructions)
+	if !ok {
+		return
+	}
+
+	address := uint16(page) << 8
+	for _, ins := range instructions {
+		if _, ok := storeLoads[ins.name]; !ok || indexedRegister(ins.addressing) == noRegister {
+			continue
+		}
+		if ins.operand()&0xff00 == address {
+			dis.Variables().AddBuffer(address, oamBufferSize, oamBufferName)
+			return
+		}
+	}
+}
+
+// oamDMAPage returns the page that is written to the OAM DMA register by an immediate load
+// followed by a store to the register.
+func oamDMAPage(instructions []instructionInfo) (byte, bool) {
+	for i := 1; i < len(instructions); i++ {
+		store := instructions[i]
+		load := instructions[i-1]
+		if store.addressing != m6502.AbsoluteAddressing || store.operand() != register.OAM_DMA ||
+			!store.follows(load) || storeLoads[store.name] != load.name ||
+			load.addressing != m6502.ImmediateAddressing {
+
+			continue
+		}
+		return byte(load.operand()), true
+	}
+	return 0, false
+}
+
+// annotateOAMAddressResets annotates the writes of zero to the OAM address register that precede
+// an OAM DMA. A DMA without a preceding reset copies the buffer to a wrong OAM offset if the OAM
+// address was changed before, which gets annotated as warning at the DMA write.
+func annotateOAMAddressResets(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if !isAbsoluteStore(ins, register.OAM_DMA) {
+			continue
+		}
+
+		reset := oamAddressReset(instructions, i)
+		if reset < 0 {
+			ins.offsetInfo.AddComment(oamAddressMissingComment)
+			continue
+		}
+		instructions[reset].offsetInfo.AddComment(oamAddressResetComment)
+	}
+}
+
+// oamAddressReset returns the index of the write of zero to the OAM address register in the
+// straight-line code before the DMA at the given index, or -1 if none was found.
+func oamAddressReset(instructions []instructionInfo, dma int) int {
+	for i := dma - 1; i >= 0 && i >= dma-oamAddressResetMaxDistance; i-- {
+		if !continuesBlock(instructions[i], instructions[i+1]) {
+			return -1
+		}
+		if !isAbsoluteStore(instructions[i], register.OAM_ADDR) {
+			continue
+		}
+
+		if value, ok := immediateStoreValue(instructions, i); ok && value == 0 {
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+// isAbsoluteStore returns whether the instruction stores a register to the given address using
+// absolute addressing.
+func isAbsoluteStore(ins instructionInfo, address uint16) bool {
+	_, ok := storeLoads[ins.name]
+	return ok && ins.addressing == m6502.AbsoluteAddressing && ins.operand() == address
+}
@@ -0,0 +1,81 @@
+package m6502
+
+import (
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const openBusComment = "open bus / unmapped read"
+
+// address ranges of the CPU memory map that are not backed by the console itself.
+const (
+	openBusStart   = 0x4018 // APU and I/O test mode registers, disabled on retail consoles
+	openBusEnd     = 0x401f
+	expansionStart = 0x4020 // cartridge expansion area, only mapped by some mappers
+	expansionEnd   = 0x5fff
+)
+
+// expansionMappers contains the mappers that have registers or memory in the cartridge
+// expansion area. The whole area is treated as mapped for them, as most of these mappers
+// mirror their registers across it. Reads of the expansion area of mappers that are not
+// listed get annotated as open bus, which includes rare mappers with expansion registers.
+var expansionMappers = map[byte]struct{}{
+	5:   {}, // MMC5
+	19:  {}, // Namco 163
+	20:  {}, // Famicom Disk System
+	28:  {}, // Action 53
+	36:  {}, // TXC 01-22000-400
+	50:  {}, // N-32 conversion of Super Mario Bros. 2 (J)
+	79:  {}, // NINA-03/NINA-06
+	83:  {}, // Cony
+	90:  {}, // J.Y. Company
+	111: {}, // GTROM
+	113: {}, // NINA-03/NINA-06 multicart
+	132: {}, // TXC 01-22270-000
+	137: {}, // Sachen 8259D
+	138: {}, // Sachen 8259B
+	139: {}, // Sachen 8259C
+	141: {}, // Sachen 8259A
+	143: {}, // Sachen TCA01
+	145: {}, // Sachen SA-72007
+	146: {}, // Sachen 3015
+	147: {}, // Sachen TC-U01-1.5M
+	150: {}, // Sachen SA-015
+	163: {}, // Nanjing
+	164: {}, // Dongda
+	173: {}, // Idea-Tek
+	176: {}, // BMC-FK23C
+	178: {}, // Education Computer
+	186: {}, // Fukutake Study Box
+	209: {}, // J.Y. Company
+	211: {}, // J.Y. Company
+}
+
+// detectOpenBusReads annotates all absolute reads of addresses that are not backed by memory or
+// a register for the mapper of the cartridge. These reads return the open bus value and usually
+// indicate data that was decoded as code.
+func detectOpenBusReads(mapper byte, instructions []instructionInfo) {
+	for _, ins := range instructions {
+		switch ins.addressing {
+		case m6502.AbsoluteAddressing, m6502.AbsoluteXAddressing, m6502.AbsoluteYAddressing:
+		default:
+			continue
+		}
+		if !ins.offsetInfo.Opcode.ReadsMemory() || isMappedAddress(mapper, ins.operand()) {
+			continue
+		}
+		ins.offsetInfo.AddComment(openBusComment)
+	}
+}
+
+// isMappedAddress returns whether the address is backed by memory or a register for the given mapper.
+func isMappedAddress(mapper byte, address uint16) bool {
+	switch {
+	case address >= openBusStart && address <= openBusEnd:
+		return false
+	case address >= expansionStart && address <= expansionEnd:
+		_, ok := expansionMappers[mapper]
+		return ok
+	default:
+		return true
+	}
+}
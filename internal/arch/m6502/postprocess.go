@@ -0,0 +1,136 @@
+package m6502
+
+import (
+	"slices"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+// instructionInfo contains a decoded instruction that is used by the post processing detectors.
+type instructionInfo struct {
+	address    uint16
+	offsetInfo *arch.Offset
+	name       string
+	addressing m6502.AddressingMode
+}
+
+// PostProcess runs the pattern detectors over the decoded code after the execution flow
+// has been followed. The detectors annotate well known code idioms.
+func (ar *Arch6502) PostProcess(dis arch.Disasm) {
+	instructions := collectInstructions(dis)
+	opts := dis.Options()
+	cart := dis.Cart()
+
+	if opts.AnnotateMMC1 && cart.Mapper == 1 {
+		detectMMC1Writes(instructions)
+	}
+	if cart.Mapper == 4 {
+		annotateMMC3IRQWrites(instructions)
+	}
+	detectFarCalls(dis, cart.Mapper, instructions)
+	detectBankTableSwitches(dis, instructions)
+	annotateDecimalMode(instructions)
+	if opts.AnnotateChecksum {
+		detectChecksumLoops(dis, instructions)
+	}
+	detectMultiplications(instructions)
+	detectNegations(instructions)
+	detectElementTableAccesses(instructions)
+	detectWordIncrements(dis, instructions)
+	detectDelayLoops(instructions)
+	annotateCountedLoops(instructions)
+	detectNOPSleds(instructions)
+	detectFrameFlagWaits(dis, instructions)
+	detectInterruptRegisterSaves(dis, instructions)
+	detectOAMBuffer(dis, instructions)
+	annotateOAMAddressResets(instructions)
+	detectRAMFillLoops(dis, instructions)
+	detectPPUUploadLoops(dis, instructions)
+	detectPPUUnrolledUploads(instructions)
+	detectPPULatchResets(instructions)
+	detectPPUBufferedReads(instructions)
+	annotatePPUScrollWrites(instructions)
+	annotatePPUControlWrites(instructions)
+	annotatePPUMaskWrites(instructions)
+	annotateAPUWrites(instructions)
+	detectAPUSilence(dis, instructions)
+	detectOpenBusReads(cart.Mapper, instructions)
+	detectSentinelTables(dis, instructions)
+	detectRTSTrampolines(instructions)
+	detectCodeTables(instructions)
+	if opts.StackCheck {
+		detectStackImbalances(dis, instructions)
+	}
+	if opts.FunctionMetrics {
+		annotateFunctionMetrics(instructions)
+	}
+	processComplementaryBranches(dis, instructions)
+	detectAddressBytes(dis, ar.syntax, instructions)
+}
+
+// collectInstructions returns all decoded instructions of the currently mapped banks sorted by address.
+func collectInstructions(dis arch.Disasm) []instructionInfo {
+	var instructions []instructionInfo
+	mapper := dis.Mapper()
+
+	for address := uint32(dis.CodeBaseAddress()); address < m6502.InterruptVectorStartAddress; {
+		offsetInfo := mapper.OffsetInfo(uint16(address))
+		if offsetInfo == nil || !offsetInfo.IsType(program.CodeOffset) ||
+			len(offsetInfo.Data) == 0 || offsetInfo.Opcode == nil {
+
+			address++
+			continue
+		}
+
+		instructions = append(instructions, instructionInfo{
+			address:    uint16(address),
+			offsetInfo: offsetInfo,
+			name:       offsetInfo.Opcode.Instruction().Name(),
+			addressing: m6502.AddressingMode(offsetInfo.Opcode.Addressing()),
+		})
+		address += uint32(len(offsetInfo.Data))
+	}
+	return instructions
+}
+
+// operand returns the raw operand value of the instruction.
+func (i instructionInfo) operand() uint16 {
+	data := i.offsetInfo.Data
+	switch len(data) {
+	case 2:
+		return uint16(data[1])
+	case 3:
+		return uint16(data[2])<<8 | uint16(data[1])
+	default:
+		return 0
+	}
+}
+
+// branchTarget returns the destination address of a relative branch instruction.
+func (i instructionInfo) branchTarget() uint16 {
+	offset := int8(i.offsetInfo.Data[1])
+	return uint16(int(i.address) + 2 + int(offset))
+}
+
+// follows returns whether the instruction directly follows the previous instruction
+// without being a branch destination, which makes both part of the same straight-line code.
+func (i instructionInfo) follows(previous instructionInfo) bool {
+	if previous.address+uint16(len(previous.offsetInfo.Data)) != i.address {
+		return false
+	}
+	return i.offsetInfo.Label == "" && len(i.offsetInfo.BranchFrom) == 0
+}
+
+// instructionIndex returns the index of the instruction at the given address or -1 if the
+// address is not the start of an instruction.
+func instructionIndex(instructions []instructionInfo, address uint16) int {
+	index, found := slices.BinarySearchFunc(instructions, address, func(ins instructionInfo, address uint16) int {
+		return int(ins.address) - int(address)
+	})
+	if !found {
+		return -1
+	}
+	return index
+}
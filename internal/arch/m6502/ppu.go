@@ -0,0 +1,360 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+	"github.com/retroenv/retrogolib/arch/nes/register"
+)
+
+const (
+	attributesNaming    = "attributes_%04x"
+	nametableDataNaming = "nametable_data_%04x"
+	paletteDataNaming   = "palette_%04x"
+)
+
+const (
+	attributeTableOffset = 0x3c0 // offset of the attribute table inside of a nametable
+	attributeTableSize   = 64    // size of the attribute table of a nametable in bytes
+	nametableStart       = 0x2000
+	nametableEnd         = 0x2fff
+	nametableSize        = 0x400
+)
+
+const (
+	paletteAddress   = 0x3f00 // PPU address of the palette RAM
+	paletteSize      = 32     // size of the palette RAM in bytes
+	subPaletteSize   = 4      // number of colors of a single background or sprite palette
+	subPaletteGroups = 4      // number of palettes for background and sprites
+)
+
+// ppuAddressSetupMaxSize is the maximum number of instructions that are checked before an upload
+// loop for the writes of the PPU address.
+const ppuAddressSetupMaxSize = 6
+
+const (
+	ppuBufferedReadComment = "PPU buffered read (dummy + real)"
+	ppuLatchResetComment   = "reset PPU $2006 latch"
+)
+
+// ppuStatusLoopMaxSize is the maximum number of instructions that are checked after a read of the
+// PPU status register for a branch back that makes the read part of a wait loop.
+const ppuStatusLoopMaxSize = 2
+
+// ppuStatusReads contains the instructions that are used to read the PPU status register.
+var ppuStatusReads = map[string]struct{}{
+	m6502.Bit.Name: {},
+	m6502.Lda.Name: {},
+	m6502.Ldx.Name: {},
+	m6502.Ldy.Name: {},
+}
+
+// ppuUploadLoopMaxSize is the maximum number of instructions that are checked between the
+// write to the PPU data register and the branch back to the loop start.
+const ppuUploadLoopMaxSize = 4
+
+// ppuUploadLoop contains the information of a detected counted PPU upload loop.
+type ppuUploadLoop struct {
+	start int    // index of the first instruction of the loop
+	table uint16 // address of the source table
+	count int    // number of bytes that are uploaded
+}
+
+// detectPPUUploadLoops detects counted loops that copy bytes from a table to the PPU data register.
+// The source table is labeled as nametable data and sized to the number of uploaded bytes. Uploads
+// to the palette RAM or an attribute table are labeled as such and limited to their maximum size.
+func detectPPUUploadLoops(dis arch.Disasm, instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if ins.name != m6502.Sta.Name || ins.addressing != m6502.AbsoluteAddressing ||
+			ins.operand() != register.PPU_DATA {
+
+			continue
+		}
+
+		loop, ok := ppuUploadLoopInfo(instructions, i)
+		if !ok {
+			continue
+		}
+
+		address, ok := ppuAddressSetup(instructions, loop.start)
+		switch {
+		case ok && address == paletteAddress:
+			size := min(loop.count, paletteSize)
+			if setDataTable(dis, loop.table, size, paletteDataNaming) {
+				annotatePalettes(dis, loop.table, size)
+			}
+		case ok && isAttributeTable(address):
+			setDataTable(dis, loop.table, min(loop.count, attributeTableSize), attributesNaming)
+		default:
+			setDataTable(dis, loop.table, loop.count, nametableDataNaming)
+		}
+	}
+}
+
+// ppuAddressSetup returns the PPU address that is set by the two writes of immediate values to
+// the PPU address register in the straight-line code before the instruction at the given index.
+func ppuAddressSetup(instructions []instructionInfo, index int) (uint16, bool) {
+	var values []uint16
+
+	for i := index - 1; i > 0 && i >= index-ppuAddressSetupMaxSize && len(values) < 2; i-- {
+		ins := instructions[i]
+		next := instructions[i+1]
+		if ins.address+uint16(len(ins.offsetInfo.Data)) != next.address || (i+1 < index && !next.follows(ins)) {
+			return 0, false
+		}
+		if ins.name != m6502.Sta.Name || ins.addressing != m6502.AbsoluteAddressing ||
+			ins.operand() != register.PPU_ADDR {
+
+			continue
+		}
+
+		load := instructions[i-1]
+		if load.name != m6502.Lda.Name || load.addressing != m6502.ImmediateAddressing || !ins.follows(load) {
+			return 0, false
+		}
+		values = append(values, load.operand())
+	}
+
+	if len(values) != 2 {
+		return 0, false
+	}
+	return values[1]<<8 | values[0], true
+}
+
+// isAttributeTable returns whether the PPU address is the start of the attribute table of
+// one of the nametables.
+func isAttributeTable(address uint16) bool {
+	return address >= nametableStart && address <= nametableEnd && address%nametableSize == attributeTableOffset
+}
+
+// annotatePalettes splits the palette data of the given size at the given address into single
+// bytes and annotates every byte with the background or sprite palette and the color index
+// inside of the palette that it sets.
+func annotatePalettes(dis arch.Disasm, address uint16, size int) {
+	mapper := dis.Mapper()
+
+	for i := range size {
+		palette := i / subPaletteSize
+		kind := "background"
+		if palette >= subPaletteGroups {
+			kind = "sprite"
+		}
+
+		offsetInfo := mapper.OffsetInfo(address + uint16(i))
+		offsetInfo.AddComment(fmt.Sprintf("%s palette %d color %d", kind, palette%subPaletteGroups, i%subPaletteSize))
+		offsetInfo.SetType(program.DataBlockEnd)
+	}
+}
+
+// annotatePPUScrollWrites annotates the writes to the PPU scroll register. The PPU uses a shared
+// address latch for the scroll and address registers, the first write sets the X scroll and the
+// second write the Y scroll. The latch is reset by reading the PPU status register.
+func annotatePPUScrollWrites(instructions []instructionInfo) {
+	var secondWrite bool
+
+	for i, ins := range instructions {
+		if i > 0 && !ins.follows(instructions[i-1]) {
+			secondWrite = false // the latch state is unknown at a branch destination
+		}
+		if isPPUStatusRead(ins) {
+			secondWrite = false
+			continue
+		}
+
+		if _, ok := storeLoads[ins.name]; !ok || ins.addressing != m6502.AbsoluteAddressing {
+			continue
+		}
+		switch ins.operand() {
+		case register.PPU_ADDR:
+			secondWrite = !secondWrite
+
+		case register.PPU_SCROLL:
+			axis := "X"
+			if secondWrite {
+				axis = "Y"
+			}
+			comment := "set PPU scroll " + axis
+			if value, ok := immediateStoreValue(instructions, i); ok {
+				comment += fmt.Sprintf(" to %d", value)
+			}
+			ins.offsetInfo.AddComment(comment)
+			secondWrite = !secondWrite
+		}
+	}
+}
+
+// immediateStoreValue returns the immediate value that was loaded into the register of the store
+// instruction at the given index by the directly preceding instruction.
+func immediateStoreValue(instructions []instructionInfo, index int) (uint16, bool) {
+	if index == 0 {
+		return 0, false
+	}
+
+	store := instructions[index]
+	load := instructions[index-1]
+	if !store.follows(load) || load.name != storeLoads[store.name] || load.addressing != m6502.ImmediateAddressing {
+		return 0, false
+	}
+	return load.operand(), true
+}
+
+// isPPUStatusRead returns whether the instruction reads the PPU status register.
+func isPPUStatusRead(ins instructionInfo) bool {
+	_, ok := ppuStatusReads[ins.name]
+	return ok && ins.addressing == m6502.AbsoluteAddressing && ins.operand() == register.PPU_STATUS
+}
+
+// detectPPULatchResets annotates isolated reads of the PPU status register. A read that is not part
+// of a loop waiting for the vblank flag is usually done to reset the address latch that is shared
+// by the PPU scroll and address registers.
+func detectPPULatchResets(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if !isPPUStatusRead(ins) {
+			continue
+		}
+
+		if !ppuStatusReadLooped(instructions, i) {
+			ins.offsetInfo.AddComment(ppuLatchResetComment)
+		}
+	}
+}
+
+// ppuDataReads contains the instructions that are used to read the PPU data register.
+var ppuDataReads = map[string]struct{}{
+	m6502.Lda.Name: {},
+	m6502.Ldx.Name: {},
+	m6502.Ldy.Name: {},
+}
+
+// detectPPUBufferedReads annotates two directly following reads of the PPU data register. The
+// PPU returns the content of an internal read buffer, the first read after setting the PPU address
+// is a dummy read that fills the buffer and the second read returns the actual value.
+func detectPPUBufferedReads(instructions []instructionInfo) {
+	for i := 1; i < len(instructions); i++ {
+		dummy, read := instructions[i-1], instructions[i]
+		if !isPPUDataRead(dummy) || !isPPUDataRead(read) || !continuesBlock(dummy, read) {
+			continue
+		}
+
+		dummy.offsetInfo.AddComment(ppuBufferedReadComment)
+		i++ // the real read can not be the dummy read of another pair
+	}
+}
+
+// isPPUDataRead returns whether the instruction reads the PPU data register.
+func isPPUDataRead(ins instructionInfo) bool {
+	_, ok := ppuDataReads[ins.name]
+	return ok && ins.addressing == m6502.AbsoluteAddressing && ins.operand() == register.PPU_DATA
+}
+
+// ppuStatusReadLooped returns whether the PPU status read at the given index is followed by a
+// branch back to the read or before it, which makes the read part of a wait loop.
+func ppuStatusReadLooped(instructions []instructionInfo, readIndex int) bool {
+	read := instructions[readIndex]
+
+	for i := readIndex + 1; i < len(instructions) && i <= readIndex+ppuStatusLoopMaxSize; i++ {
+		ins := instructions[i]
+		if !continuesBlock(instructions[i-1], ins) {
+			return false
+		}
+		if ins.addressing == m6502.RelativeAddressing && ins.branchTarget() <= read.address {
+			return true
+		}
+	}
+	return false
+}
+
+// ppuUploadLoopInfo returns the upload loop information for the PPU data write at the given index.
+// The loop has to load from an indexed table, write to the PPU, change the index register and
+// branch back to the load, it can optionally compare the index register against the loop end.
+// nolint: cyclop
+func ppuUploadLoopInfo(instructions []instructionInfo, writeIndex int) (ppuUploadLoop, bool) {
+	var counter, compare *instructionInfo
+	var branchIndex int
+
+	for i := writeIndex + 1; i < len(instructions) && i <= writeIndex+ppuUploadLoopMaxSize; i++ {
+		ins := &instructions[i]
+		if !ins.follows(instructions[i-1]) {
+			return ppuUploadLoop{}, false
+		}
+
+		switch ins.name {
+		case m6502.Inx.Name, m6502.Iny.Name, m6502.Dex.Name, m6502.Dey.Name:
+			counter = ins
+			compare = nil
+		case m6502.Cpx.Name, m6502.Cpy.Name:
+			if counter != nil && ins.addressing == m6502.ImmediateAddressing {
+				compare = ins
+			}
+		case m6502.Bne.Name:
+			branchIndex = i
+		}
+		if branchIndex != 0 {
+			break
+		}
+	}
+	if branchIndex == 0 || counter == nil {
+		return ppuUploadLoop{}, false
+	}
+
+	register := indexRegister(counter.name)
+	if compare != nil && indexRegister(compare.name) != register {
+		return ppuUploadLoop{}, false
+	}
+	loopStart := instructions[branchIndex].branchTarget()
+	startIndex := instructionIndex(instructions, loopStart)
+	if startIndex < 0 || startIndex > writeIndex {
+		return ppuUploadLoop{}, false
+	}
+
+	// find the indexed table load inside the loop
+	var table uint16
+	var tableFound bool
+	for i := startIndex; i < writeIndex; i++ {
+		ins := instructions[i]
+		if ins.name == m6502.Lda.Name && indexedRegister(ins.addressing) == register {
+			table = ins.operand()
+			tableFound = true
+		}
+	}
+	if !tableFound {
+		return ppuUploadLoop{}, false
+	}
+
+	initial, ok := registerInitializer(instructions, startIndex, register)
+	if !ok {
+		return ppuUploadLoop{}, false
+	}
+
+	count := loopIterations(counter.name, compare, initial)
+	if count <= 0 {
+		return ppuUploadLoop{}, false
+	}
+	return ppuUploadLoop{start: startIndex, table: table, count: count}, true
+}
+
+// setDataTable labels a data table at the given address and marks the end of the table
+// to be able to output it as separate data block. It returns whether the table was set.
+func setDataTable(dis arch.Disasm, address uint16, size int, naming string) bool {
+	mapper := dis.Mapper()
+	end := uint32(address) + uint32(size) - 1
+	if address < dis.CodeBaseAddress() || end >= m6502.InterruptVectorStartAddress {
+		return false
+	}
+
+	for addr := uint32(address); addr <= end; addr++ {
+		offsetInfo := mapper.OffsetInfo(uint16(addr))
+		if offsetInfo == nil || offsetInfo.IsType(program.CodeOffset|program.CodeAsData) {
+			return false
+		}
+	}
+
+	offsetInfo := mapper.OffsetInfo(address)
+	if offsetInfo.Label == "" {
+		offsetInfo.Label = fmt.Sprintf(naming, address)
+	}
+	mapper.OffsetInfo(uint16(end)).SetType(program.DataBlockEnd)
+	return true
+}
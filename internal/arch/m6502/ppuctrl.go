@@ -0,0 +1,74 @@
+package m6502
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+	"github.com/retroenv/retrogolib/arch/nes/register"
+)
+
+// PPU control register bits.
+const (
+	ppuCtrlNametable         = 0b0000_0011 // base nametable address
+	ppuCtrlIncrement         = 0b0000_0100 // VRAM address increment per PPU data access
+	ppuCtrlSpritePattern     = 0b0000_1000 // sprite pattern table address for 8x8 sprites
+	ppuCtrlBackgroundPattern = 0b0001_0000 // background pattern table address
+	ppuCtrlSpriteSize        = 0b0010_0000 // sprite size
+	ppuCtrlNMI               = 0b1000_0000 // generate an NMI at the start of vblank
+)
+
+// annotatePPUControlWrites annotates the writes of immediate values to the PPU control register
+// with the decoded configuration.
+func annotatePPUControlWrites(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if _, ok := storeLoads[ins.name]; !ok || ins.addressing != m6502.AbsoluteAddressing ||
+			ins.operand() != register.PPU_CTRL {
+
+			continue
+		}
+
+		value, ok := immediateStoreValue(instructions, i)
+		if !ok {
+			continue
+		}
+		ins.offsetInfo.AddComment("PPUCTRL: " + decodePPUControl(byte(value)))
+	}
+}
+
+// decodePPUControl returns a description of the configuration that is set by writing the value
+// to the PPU control register.
+func decodePPUControl(value byte) string {
+	var fields []string
+
+	if value&ppuCtrlNMI != 0 {
+		fields = append(fields, "NMI on")
+	} else {
+		fields = append(fields, "NMI off")
+	}
+
+	if value&ppuCtrlSpriteSize != 0 {
+		fields = append(fields, "8x16 sprites")
+	} else {
+		fields = append(fields, "8x8 sprites", "sprites "+patternTable(value&ppuCtrlSpritePattern != 0))
+	}
+
+	fields = append(fields, "BG "+patternTable(value&ppuCtrlBackgroundPattern != 0))
+	fields = append(fields, fmt.Sprintf("nametable $%04X", 0x2000+int(value&ppuCtrlNametable)*0x400))
+
+	if value&ppuCtrlIncrement != 0 {
+		fields = append(fields, "VRAM +32")
+	} else {
+		fields = append(fields, "VRAM +1")
+	}
+
+	return strings.Join(fields, ", ")
+}
+
+// patternTable returns the address of the selected pattern table.
+func patternTable(high bool) string {
+	if high {
+		return "$1000"
+	}
+	return "$0000"
+}
@@ -0,0 +1,77 @@
+package m6502
+
+import (
+	"strings"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+	"github.com/retroenv/retrogolib/arch/nes/register"
+)
+
+// PPU mask register bits.
+const (
+	ppuMaskGrayscale      = 0b0000_0001 // display in grayscale
+	ppuMaskBackgroundLeft = 0b0000_0010 // show the background in the leftmost 8 pixels of the screen
+	ppuMaskSpritesLeft    = 0b0000_0100 // show sprites in the leftmost 8 pixels of the screen
+	ppuMaskBackground     = 0b0000_1000 // show the background
+	ppuMaskSprites        = 0b0001_0000 // show sprites
+	ppuMaskEmphasizeRed   = 0b0010_0000 // emphasize red
+	ppuMaskEmphasizeGreen = 0b0100_0000 // emphasize green
+	ppuMaskEmphasizeBlue  = 0b1000_0000 // emphasize blue
+)
+
+// annotatePPUMaskWrites annotates the writes of immediate values to the PPU mask register
+// with the decoded rendering configuration.
+func annotatePPUMaskWrites(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if _, ok := storeLoads[ins.name]; !ok || ins.addressing != m6502.AbsoluteAddressing ||
+			ins.operand() != register.PPU_MASK {
+
+			continue
+		}
+
+		value, ok := immediateStoreValue(instructions, i)
+		if !ok {
+			continue
+		}
+		ins.offsetInfo.AddComment("PPUMASK: " + decodePPUMask(byte(value)))
+	}
+}
+
+// decodePPUMask returns a description of the rendering configuration that is set by writing the
+// value to the PPU mask register. The left column clipping is only listed for enabled layers.
+func decodePPUMask(value byte) string {
+	var fields []string
+
+	if value&ppuMaskBackground != 0 {
+		fields = append(fields, "BG on")
+		if value&ppuMaskBackgroundLeft == 0 {
+			fields = append(fields, "BG left clipped")
+		}
+	} else {
+		fields = append(fields, "BG off")
+	}
+
+	if value&ppuMaskSprites != 0 {
+		fields = append(fields, "sprites on")
+		if value&ppuMaskSpritesLeft == 0 {
+			fields = append(fields, "sprites left clipped")
+		}
+	} else {
+		fields = append(fields, "sprites off")
+	}
+
+	if value&ppuMaskGrayscale != 0 {
+		fields = append(fields, "grayscale")
+	}
+	if value&ppuMaskEmphasizeRed != 0 {
+		fields = append(fields, "emphasize red")
+	}
+	if value&ppuMaskEmphasizeGreen != 0 {
+		fields = append(fields, "emphasize green")
+	}
+	if value&ppuMaskEmphasizeBlue != 0 {
+		fields = append(fields, "emphasize blue")
+	}
+
+	return strings.Join(fields, ", ")
+}
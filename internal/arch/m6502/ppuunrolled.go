@@ -0,0 +1,63 @@
+package m6502
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+	"github.com/retroenv/retrogolib/arch/nes/register"
+)
+
+const ppuUnrolledUploadComment = "unrolled PPU upload, %d bytes"
+
+// ppuUnrolledUploadMinStores is the minimum number of consecutive writes to the PPU data register
+// that are detected as unrolled upload.
+const ppuUnrolledUploadMinStores = 4
+
+// detectPPUUnrolledUploads detects runs of straight-line writes to the PPU data register that are
+// only interleaved by loads of the written values, which is a common optimization of uploads of
+// small amounts of graphics data. The first instruction of the run is annotated.
+func detectPPUUnrolledUploads(instructions []instructionInfo) {
+	for i := 0; i < len(instructions); i++ {
+		if !isPPUDataLoadOrStore(instructions[i]) {
+			continue
+		}
+
+		end, stores := ppuUnrolledUploadRun(instructions, i)
+		if stores >= ppuUnrolledUploadMinStores {
+			instructions[i].offsetInfo.AddComment(fmt.Sprintf(ppuUnrolledUploadComment, stores))
+		}
+		i = end
+	}
+}
+
+// ppuUnrolledUploadRun returns the index of the last instruction of the run of loads and PPU data
+// writes that starts at the given index and the number of writes of the run.
+func ppuUnrolledUploadRun(instructions []instructionInfo, start int) (int, int) {
+	var stores int
+	end := start
+
+	for i := start; i < len(instructions); i++ {
+		ins := instructions[i]
+		if !isPPUDataLoadOrStore(ins) || (i > start && !ins.follows(instructions[i-1])) {
+			break
+		}
+		if _, ok := storeLoads[ins.name]; ok {
+			stores++
+		}
+		end = i
+	}
+	return end, stores
+}
+
+// isPPUDataLoadOrStore returns whether the instruction is a register load or a write of a register
+// to the PPU data register.
+func isPPUDataLoadOrStore(ins instructionInfo) bool {
+	switch ins.name {
+	case m6502.Lda.Name, m6502.Ldx.Name, m6502.Ldy.Name:
+		return true
+	case m6502.Sta.Name, m6502.Stx.Name, m6502.Sty.Name:
+		return ins.addressing == m6502.AbsoluteAddressing && ins.operand() == register.PPU_DATA
+	default:
+		return false
+	}
+}
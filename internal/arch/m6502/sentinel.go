@@ -0,0 +1,67 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const sentinelDataNaming = "sentinel_data_%04x"
+
+// sentinelTableMaxSize is the maximum size of a sentinel terminated table, the table is
+// read using an 8 bit index register.
+const sentinelTableMaxSize = 0x100
+
+// sentinelCompares maps the load instructions to the compare instruction that uses the same register.
+var sentinelCompares = map[string]string{
+	m6502.Lda.Name: m6502.Cmp.Name,
+	m6502.Ldx.Name: m6502.Cpx.Name,
+	m6502.Ldy.Name: m6502.Cpy.Name,
+}
+
+// detectSentinelTables detects indexed table reads that compare the read value against an immediate
+// sentinel value and branch on the result. The table is labeled and sized up to and including the
+// first occurrence of the sentinel value.
+func detectSentinelTables(dis arch.Disasm, instructions []instructionInfo) {
+	for i := 0; i+2 < len(instructions); i++ {
+		load := instructions[i]
+		compareName, ok := sentinelCompares[load.name]
+		if !ok || (load.addressing != m6502.AbsoluteXAddressing && load.addressing != m6502.AbsoluteYAddressing) {
+			continue
+		}
+
+		compare := instructions[i+1]
+		branch := instructions[i+2]
+		if !compare.follows(load) || !branch.follows(compare) ||
+			compare.name != compareName || compare.addressing != m6502.ImmediateAddressing ||
+			(branch.name != m6502.Beq.Name && branch.name != m6502.Bne.Name) {
+
+			continue
+		}
+
+		table := load.operand()
+		size, ok := sentinelTableSize(dis, table, byte(compare.operand()))
+		if !ok {
+			continue
+		}
+		setDataTable(dis, table, size, sentinelDataNaming)
+	}
+}
+
+// sentinelTableSize returns the size of the table at the given address including the sentinel value.
+func sentinelTableSize(dis arch.Disasm, address uint16, sentinel byte) (int, bool) {
+	for i := range sentinelTableMaxSize {
+		addr := uint32(address) + uint32(i)
+		if addr >= m6502.InterruptVectorStartAddress {
+			return 0, false
+		}
+
+		b, err := dis.ReadMemory(uint16(addr))
+		if err != nil {
+			return 0, false
+		}
+		if b == sentinel {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
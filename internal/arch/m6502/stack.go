@@ -0,0 +1,120 @@
+package m6502
+
+import (
+	"strings"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const stackImbalanceComment = "stack imbalance suspected"
+
+// stackDepthChanges maps the instructions that push or pull a value on the stack to the
+// change of the stack depth.
+var stackDepthChanges = map[string]int{
+	m6502.Pha.Name: 1,
+	m6502.Php.Name: 1,
+	m6502.Pla.Name: -1,
+	m6502.Plp.Name: -1,
+}
+
+// stackPath is a code path of a function that gets checked with the stack depth at its start.
+type stackPath struct {
+	index int
+	depth int
+}
+
+// detectStackImbalances follows all code paths of every function and tracks the number of values
+// pushed on the stack. A function that returns with values left on the stack or pulls more values
+// than it pushed is likely data that was decoded as code, the instruction where the imbalance
+// was found gets annotated.
+func detectStackImbalances(dis arch.Disasm, instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if ins.offsetInfo.IsType(program.CallDestination) && !ins.offsetInfo.IsType(program.JumpEngine) {
+			checkStackBalance(dis, instructions, i)
+		}
+	}
+}
+
+// checkStackBalance checks the stack balance of all code paths of the function starting at
+// the given instruction index.
+func checkStackBalance(dis arch.Disasm, instructions []instructionInfo, start int) {
+	visited := map[int]int{}
+	paths := []stackPath{{index: start}}
+
+	for len(paths) > 0 {
+		path := paths[len(paths)-1]
+		paths = paths[:len(paths)-1]
+
+		for index, depth := path.index, path.depth; index >= 0; {
+			visitedDepth, ok := visited[index]
+			if ok {
+				if visitedDepth != depth {
+					annotateStackImbalance(dis, instructions[index])
+				}
+				break
+			}
+			visited[index] = depth
+
+			ins := instructions[index]
+			depth += stackDepthChanges[ins.name]
+			if depth < 0 {
+				annotateStackImbalance(dis, ins)
+				break
+			}
+
+			next, destination, done := stackPathSuccessors(instructions, index)
+			if done && (ins.name == m6502.Rts.Name || ins.name == m6502.Rti.Name) && depth != 0 {
+				annotateStackImbalance(dis, ins)
+			}
+			if destination >= 0 {
+				paths = append(paths, stackPath{index: destination, depth: depth})
+			}
+			if done {
+				break
+			}
+			index = next
+		}
+	}
+}
+
+// stackPathSuccessors returns the index of the following instruction and of the branch destination
+// of the instruction at the given index, or -1 if none exists. The returned bool is true if the
+// code path can not be followed any further.
+func stackPathSuccessors(instructions []instructionInfo, index int) (int, int, bool) {
+	ins := instructions[index]
+	destination := -1
+	if ins.addressing == m6502.RelativeAddressing {
+		destination = instructionIndex(instructions, ins.branchTarget())
+	}
+
+	switch ins.name {
+	case m6502.Rts.Name, m6502.Rti.Name, m6502.Brk.Name:
+		return -1, -1, true
+
+	case m6502.Jmp.Name:
+		if ins.addressing != m6502.AbsoluteAddressing {
+			return -1, -1, true
+		}
+		// jumps to other functions are tail calls that are checked as part of the called function
+		destination = instructionIndex(instructions, ins.operand())
+		if destination >= 0 && instructions[destination].offsetInfo.IsType(program.CallDestination) {
+			destination = -1
+		}
+		return -1, destination, true
+	}
+
+	next := instructionIndex(instructions, ins.address+uint16(len(ins.offsetInfo.Data)))
+	return next, destination, next < 0
+}
+
+// annotateStackImbalance adds the stack imbalance comment to the instruction once and counts
+// it as warning.
+func annotateStackImbalance(dis arch.Disasm, ins instructionInfo) {
+	if strings.Contains(ins.offsetInfo.Comment, stackImbalanceComment) {
+		return
+	}
+	ins.offsetInfo.AddComment(stackImbalanceComment)
+	dis.AddWarning(arch.StackImbalanceWarning)
+}
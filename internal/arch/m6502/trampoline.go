@@ -0,0 +1,147 @@
+package m6502
+
+import (
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/cpu/m6502"
+)
+
+const (
+	trampolineComment       = "RTS trampoline / return-address dispatch"
+	trampolineCallerComment = "calls RTS trampoline, following bytes may be data"
+)
+
+// trampolineMaxSize is the maximum number of instructions at the start of a function that are
+// checked for an access of the return address.
+const trampolineMaxSize = 16
+
+// stack page addresses of the return address bytes, relative to the stack pointer after a tsx.
+const (
+	stackPageStart = 0x0101
+	stackPageEnd   = 0x01ff
+)
+
+// detectRTSTrampolines detects subroutines that pull or modify their own return address from the stack
+// instead of returning to the caller. This dispatch trick is often used to skip an inline data table
+// that follows the call, the function and all its callers get annotated.
+func detectRTSTrampolines(instructions []instructionInfo) {
+	for i, ins := range instructions {
+		if !ins.offsetInfo.IsType(program.CallDestination) || ins.offsetInfo.IsType(program.JumpEngine) ||
+			len(ins.offsetInfo.BranchFrom) == 0 {
+
+			continue
+		}
+		if !accessesReturnAddress(instructions, i) {
+			continue
+		}
+
+		ins.offsetInfo.AddLabelComment(trampolineComment)
+		annotateTrampolineCallers(ins.offsetInfo)
+	}
+}
+
+// accessesReturnAddress returns whether the function starting at the given index pulls the return
+// address from the stack or accesses it through the stack page after transferring the stack pointer.
+func accessesReturnAddress(instructions []instructionInfo, start int) bool {
+	var depth int
+	var stackPointer bool
+
+	for i := start; i < len(instructions) && i < start+trampolineMaxSize; i++ {
+		ins := instructions[i]
+		if i > start && !continuesBlock(instructions[i-1], ins) {
+			return false
+		}
+
+		switch {
+		case ins.name == m6502.Pla.Name || ins.name == m6502.Plp.Name:
+			if depth == 0 {
+				return true
+			}
+			depth--
+
+		case ins.name == m6502.Pha.Name || ins.name == m6502.Php.Name:
+			depth++
+
+		case ins.name == m6502.Tsx.Name:
+			stackPointer = true
+
+		case stackPointer && ins.addressing == m6502.AbsoluteXAddressing:
+			if address := ins.operand(); address >= stackPageStart && address <= stackPageEnd {
+				return true
+			}
+
+		case ins.name == m6502.Rts.Name || ins.name == m6502.Rti.Name || ins.name == m6502.Jmp.Name:
+			return false
+		}
+	}
+	return false
+}
+
+// checkForRTSTrampoline stops the parsing of the bytes that follow the calls of an RTS trampoline, as
+// the trampoline does not return to the caller but skips the inline data that follows the call.
+// It gets checked when the return address access of the trampoline gets decoded and for every call
+// that gets decoded after the trampoline.
+func checkForRTSTrampoline(dis arch.Disasm, address uint16, offsetInfo *arch.Offset) {
+	name := offsetInfo.Opcode.Instruction().Name()
+	addressing := m6502.AddressingMode(offsetInfo.Opcode.Addressing())
+
+	switch {
+	case name == m6502.Jsr.Name && addressing == m6502.AbsoluteAddressing:
+		if isRTSTrampoline(dis, operandWord(offsetInfo.Data)) {
+			dis.DeleteFunctionReturnToParse(address + uint16(len(offsetInfo.Data)))
+		}
+
+	case name == m6502.Pla.Name || name == m6502.Plp.Name || addressing == m6502.AbsoluteXAddressing:
+		if isRTSTrampoline(dis, offsetInfo.Context) {
+			skipTrampolineReturns(dis, offsetInfo.Context)
+		}
+	}
+}
+
+// isRTSTrampoline returns whether the decoded function at the given address accesses its return address.
+func isRTSTrampoline(dis arch.Disasm, address uint16) bool {
+	offsetInfo := dis.Mapper().OffsetInfo(address)
+	if offsetInfo == nil || !offsetInfo.IsType(program.CallDestination) || offsetInfo.IsType(program.JumpEngine) {
+		return false
+	}
+
+	var instructions []instructionInfo
+	for len(instructions) < trampolineMaxSize {
+		offsetInfo = dis.Mapper().OffsetInfo(address)
+		if offsetInfo == nil || offsetInfo.Opcode == nil || len(offsetInfo.Data) == 0 {
+			break
+		}
+
+		instructions = append(instructions, instructionInfo{
+			address:    address,
+			offsetInfo: offsetInfo,
+			name:       offsetInfo.Opcode.Instruction().Name(),
+			addressing: m6502.AddressingMode(offsetInfo.Opcode.Addressing()),
+		})
+		address += uint16(len(offsetInfo.Data))
+	}
+	return accessesReturnAddress(instructions, 0)
+}
+
+// skipTrampolineReturns removes the return addresses of all calls of the trampoline function from
+// the addresses to parse.
+func skipTrampolineReturns(dis arch.Disasm, address uint16) {
+	for _, bankRef := range dis.Mapper().OffsetInfo(address).BranchFrom {
+		caller := bankRef.Mapped.OffsetInfo(bankRef.Index)
+		if caller.Opcode == nil || caller.Opcode.Instruction().Name() != m6502.Jsr.Name {
+			continue
+		}
+		dis.DeleteFunctionReturnToParse(bankRef.Address + uint16(len(caller.Data)))
+	}
+}
+
+// annotateTrampolineCallers annotates all call instructions of the trampoline function.
+func annotateTrampolineCallers(offsetInfo *arch.Offset) {
+	for _, bankRef := range offsetInfo.BranchFrom {
+		caller := bankRef.Mapped.OffsetInfo(bankRef.Index)
+		if caller.Opcode == nil || caller.Opcode.Instruction().Name() != m6502.Jsr.Name {
+			continue
+		}
+		caller.AddComment(trampolineCallerComment)
+	}
+}
@@ -2,6 +2,7 @@ package m6502
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/retroenv/nesgodisasm/internal/arch"
 	"github.com/retroenv/retrogolib/arch/cpu/m6502"
@@ -15,6 +16,12 @@ func (ar *Arch6502) ProcessVariableUsage(offsetInfo *arch.Offset, reference stri
 		return fmt.Errorf("getting parameter as string: %w", err)
 	}
 
+	// update the base address of an indexed access annotation to the variable name
+	if comment, ok := indexedBaseComment(addressing, fmt.Sprintf("$%04X", operandWord(offsetInfo.Data))); ok {
+		named, _ := indexedBaseComment(addressing, reference)
+		offsetInfo.Comment = strings.Replace(offsetInfo.Comment, comment, named, 1)
+	}
+
 	name := offsetInfo.Opcode.Instruction().Name()
 	switch addressing {
 	case m6502.ZeroPageAddressing, m6502.ZeroPageXAddressing, m6502.ZeroPageYAddressing:
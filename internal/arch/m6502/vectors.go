@@ -23,9 +23,13 @@ func (ar *Arch6502) Initialize(dis arch.Disasm) error {
 func (ar *Arch6502) initializeIrqHandlers(dis arch.Disasm) error {
 	logger := dis.Logger()
 	opts := dis.Options()
+	resetLabel := opts.EntryLabel
+	if resetLabel == "" {
+		resetLabel = "Reset"
+	}
 	handlers := program.Handlers{
 		NMI:   "0",
-		Reset: "Reset",
+		Reset: resetLabel,
 		IRQ:   "0",
 	}
 	mapper := dis.Mapper()
@@ -58,9 +62,9 @@ func (ar *Arch6502) initializeIrqHandlers(dis arch.Disasm) error {
 	offsetInfo := mapper.OffsetInfo(reset)
 	if offsetInfo != nil {
 		if offsetInfo.Label != "" {
-			handlers.NMI = "Reset"
+			handlers.NMI = resetLabel
 		}
-		offsetInfo.Label = "Reset"
+		offsetInfo.Label = resetLabel
 		offsetInfo.SetType(program.CallDestination)
 	}
 
@@ -6,6 +6,9 @@ type Mapper interface {
 	GetMappedBankIndex(address uint16) uint16
 	// OffsetInfo returns the offset information for the given address.
 	OffsetInfo(address uint16) *Offset
+	// PRGOffsetInfo returns the offset information for the given PRG ROM offset,
+	// independent of the currently mapped banks. It returns nil for offsets outside of the PRG ROM.
+	PRGOffsetInfo(offset int) *Offset
 }
 
 type MappedBank interface {
@@ -8,7 +8,8 @@ type Offset struct {
 
 	Opcode Opcode // opcode this offset represents
 
-	BranchFrom  []BankReference // list of all addresses that branch to this offset
-	BranchingTo string          // label to jump to if instruction branches
-	Context     uint16          // function or interrupt context that the offset is part of
+	BranchFrom    []BankReference // list of all addresses that branch to this offset
+	BranchingTo   string          // label to jump to if instruction branches
+	OperandFormat string          // format of the operand that references the branching to label, if not used directly
+	Context       uint16          // function or interrupt context that the offset is part of
 }
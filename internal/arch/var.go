@@ -4,6 +4,8 @@ import "github.com/retroenv/nesgodisasm/internal/program"
 
 // VariableManager manages variables in the disassembled program.
 type VariableManager interface {
+	// AddBuffer adds a named buffer, all accesses into the buffer get named as offset from the buffer start.
+	AddBuffer(address uint16, size int, name string)
 	// AddBank adds a new bank to the variable manager.
 	AddBank()
 	// AddReference adds a variable reference if the opcode is accessing
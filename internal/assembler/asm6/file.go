@@ -8,7 +8,6 @@ import (
 	"github.com/retroenv/nesgodisasm/internal/options"
 	"github.com/retroenv/nesgodisasm/internal/program"
 	"github.com/retroenv/nesgodisasm/internal/writer"
-	"github.com/retroenv/retrogolib/arch/nes/cartridge"
 )
 
 var headerByte = ".db $%02x %-22s ; %s\n"
@@ -46,7 +45,18 @@ type lineWrite struct {
 // nolint: ireturn
 func New(app *program.Program, options options.Disassembler, mainWriter io.Writer, newBankWriter assembler.NewBankWriter) writer.AssemblerWriter {
 	opts := writer.Options{
-		OffsetComments: options.OffsetComments,
+		AddressPrefix:        options.AddressPrefix,
+		AliasDecimal:         options.AliasDecimal,
+		BankChecksums:        options.BankChecksums,
+		CanonicalOperands:    options.CanonicalOperands,
+		GroupDataByReference: options.GroupDataByReference,
+		HexdumpData:          options.HexdumpData,
+		MaxDataRun:           options.MaxDataRun,
+		MaxLineLength:        options.MaxLineLength,
+		OffsetComments:       options.OffsetComments,
+		Provenance:           options.Provenance,
+		SourceFile:           options.SourceFile,
+		SymbolicOffsets:      options.SymbolicOffsets,
 	}
 	return FileWriter{
 		app:           app,
@@ -57,25 +67,47 @@ func New(app *program.Program, options options.Disassembler, mainWriter io.Write
 	}
 }
 
+// headerWrites returns the writes of the iNES header.
+func (f FileWriter) headerWrites() []any {
+	control1, control2 := f.app.ControlBytes()
+
+	writes := []any{
+		lineWrite{line: ".db \"NES\", $1a", comment: "Magic string that always begins an iNES header"},
+		headerByteWrite{value: byte(f.app.PrgSize() / 16384), comment: "Number of 16KB PRG-ROM banks"},
+		headerByteWrite{value: byte(len(f.app.CHR) / 8192), comment: "Number of 8KB CHR-ROM banks"},
+		headerByteWrite{value: control1, comment: "Control bits 1"},
+		headerByteWrite{value: control2, comment: "Control bits 2"},
+		headerByteWrite{value: f.app.RAM, comment: "Number of 8KB PRG-RAM banks"},
+		headerByteWrite{value: f.app.VideoFormat, comment: "Video format NTSC/PAL"},
+	}
+	if !f.app.NES2 {
+		return append(writes, lineWrite{line: ".dsb 6", comment: "Padding to fill 16 BYTE iNES Header"})
+	}
+
+	writes[4] = headerByteWrite{value: control2, comment: "Control bits 2, " + f.app.ConsoleTypeName()}
+	writes[5] = headerByteWrite{value: f.app.MapperMSB, comment: "Mapper MSB and submapper"}
+	writes[6] = headerByteWrite{value: f.app.ROMSizeMSB, comment: "PRG-ROM and CHR-ROM size MSB"}
+	return append(writes,
+		headerByteWrite{value: f.app.RAMShifts[0], comment: "PRG-RAM size shift count"},
+		headerByteWrite{value: f.app.RAMShifts[1], comment: "CHR-RAM size shift count"},
+		headerByteWrite{value: f.app.Timing, comment: f.app.TimingName() + " timing"},
+		headerByteWrite{value: f.app.VsType, comment: "Vs. System PPU and hardware type"},
+		headerByteWrite{value: f.app.MiscROMs, comment: "Number of miscellaneous ROMs"},
+		headerByteWrite{value: f.app.ExpansionDevice, comment: "Default expansion device"},
+	)
+}
+
 // Write writes the assembly file content including header, footer, code and data.
 // nolint:funlen, cyclop
 func (f FileWriter) Write() error {
-	control1, control2 := cartridge.ControlBytes(f.app.Battery, byte(f.app.Mirror), f.app.Mapper, len(f.app.Trainer) > 0)
-
 	var writes []any // nolint:prealloc
 
 	if !f.options.CodeOnly {
-		writes = []any{
-			customWrite(f.writer.WriteCommentHeader),
-			lineWrite{line: ".db \"NES\", $1a", comment: "Magic string that always begins an iNES header"},
-			headerByteWrite{value: byte(f.app.PrgSize() / 16384), comment: "Number of 16KB PRG-ROM banks"},
-			headerByteWrite{value: byte(len(f.app.CHR) / 8192), comment: "Number of 8KB CHR-ROM banks"},
-			headerByteWrite{value: control1, comment: "Control bits 1"},
-			headerByteWrite{value: control2, comment: "Control bits 2"},
-			headerByteWrite{value: f.app.RAM, comment: "Number of 8KB PRG-RAM banks"},
-			headerByteWrite{value: f.app.VideoFormat, comment: "Video format NTSC/PAL"},
-			lineWrite{line: ".dsb 6", comment: "Padding to fill 16 BYTE iNES Header"},
-		}
+		writes = append([]any{customWrite(f.writer.WriteCommentHeader)}, f.headerWrites()...)
+	}
+
+	if f.options.TableOfContents {
+		writes = append(writes, customWrite(f.writer.WriteTableOfContents))
 	}
 
 	for i, bank := range f.app.PRG {
@@ -93,6 +125,10 @@ func (f FileWriter) Write() error {
 		customWrite(f.writeCHR),
 	)
 
+	if f.options.XrefIndex {
+		writes = append(writes, customWrite(f.writer.WriteCrossReferenceIndex))
+	}
+
 	for _, write := range writes {
 		switch t := write.(type) {
 		case headerByteWrite:
@@ -139,9 +175,9 @@ func (f FileWriter) writeBank(w prgBankWrite) error {
 			return err
 		}
 	} else {
-		nmi := fmt.Sprintf("$%04X", w.bank.Vectors[0])
-		reset := fmt.Sprintf("$%04X", w.bank.Vectors[1])
-		irq := fmt.Sprintf("$%04X", w.bank.Vectors[2])
+		nmi := f.bankVector(w.bank, w.bank.Vectors[0])
+		reset := f.bankVector(w.bank, w.bank.Vectors[1])
+		irq := f.bankVector(w.bank, w.bank.Vectors[2])
 		if err := f.writeVectors(nmi, reset, irq); err != nil {
 			return err
 		}
@@ -149,6 +185,16 @@ func (f FileWriter) writeBank(w prgBankWrite) error {
 	return nil
 }
 
+// bankVector returns the label at the vector address in the bank or the address if the bank
+// does not contain a label at the address.
+func (f FileWriter) bankVector(bank *program.PRGBank, address uint16) string {
+	index := int(address) - int(f.app.CodeBaseAddress)
+	if index >= 0 && index < len(bank.Offsets) && bank.Offsets[index].Label != "" {
+		return bank.Offsets[index].Label
+	}
+	return fmt.Sprintf("$%04X", address)
+}
+
 // writeSegment writes a segment header to the output.
 func (f FileWriter) writeSegment(address string) error {
 	_, err := fmt.Fprintf(f.mainWriter, "\n.base %s\n\n", address)
@@ -160,7 +206,7 @@ func (f FileWriter) writeSegment(address string) error {
 
 // writeConstants writes constant aliases to the output.
 func (f FileWriter) writeConstants(bank *program.PRGBank) error {
-	if err := f.writer.OutputAliasMap(bank.Constants); err != nil {
+	if err := f.writer.OutputGroupedAliasMap(bank.Constants, bank.ConstantGroups); err != nil {
 		return fmt.Errorf("writing constants output alias map: %w", err)
 	}
 	return nil
@@ -176,6 +222,19 @@ func (f FileWriter) writeVariables(bank *program.PRGBank) error {
 
 // writeCHR writes the CHR content to the output.
 func (f FileWriter) writeCHR() error {
+	if f.options.CHRSummary {
+		if err := f.writer.WriteCHRSummary(); err != nil {
+			return fmt.Errorf("writing CHR summary: %w", err)
+		}
+	}
+
+	if f.options.CHRFile != "" {
+		if err := f.writer.WriteCHRFile(f.newBankWriter, f.options.CHRFile); err != nil {
+			return fmt.Errorf("writing CHR data: %w", err)
+		}
+		return nil
+	}
+
 	if f.options.ZeroBytes {
 		if err := f.writer.BundleDataWrites(f.app.CHR, nil); err != nil {
 			return fmt.Errorf("writing CHR data: %w", err)
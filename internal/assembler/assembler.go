@@ -2,7 +2,9 @@
 package assembler
 
 import (
+	"fmt"
 	"io"
+	"strings"
 )
 
 const (
@@ -12,5 +14,35 @@ const (
 )
 
 // NewBankWriter is a callback that creates a new file for a bank of ROMs
-// that have multiple PRG banks.
+// that have multiple PRG banks. A base name that contains a file extension
+// is used as file name of a side file in the directory of the main file.
 type NewBankWriter func(baseName string) (io.WriteCloser, error)
+
+// MakefileHeader is the first line of a generated Makefile.
+const MakefileHeader = "# Makefile to rebuild the ROM from the disassembled source, generated by nesgodisasm"
+
+// MakefileRule defines the rule of a Makefile that builds a ROM from the assembly output.
+type MakefileRule struct {
+	Target        string   // file name of the built ROM
+	Dependencies  []string // files that the ROM gets built from
+	Commands      []string // commands that build the ROM
+	Intermediates []string // files that are created while building and get removed by the clean target
+}
+
+// Makefile returns the content of a Makefile that builds the ROM using the rule.
+func Makefile(rule MakefileRule) string {
+	var buf strings.Builder
+
+	buf.WriteString(MakefileHeader + "\n\n")
+	fmt.Fprintf(&buf, "%s: %s\n", rule.Target, strings.Join(rule.Dependencies, " "))
+	for _, command := range rule.Commands {
+		fmt.Fprintf(&buf, "\t%s\n", command)
+	}
+
+	buf.WriteString("\nclean:\n")
+	if len(rule.Intermediates) > 0 {
+		fmt.Fprintf(&buf, "\trm -f %s\n", strings.Join(rule.Intermediates, " "))
+	}
+	buf.WriteString("\n.PHONY: clean\n")
+	return buf.String()
+}
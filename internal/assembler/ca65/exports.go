@@ -0,0 +1,136 @@
+package ca65
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// symbolExpression matches all symbol names in an instruction operand, the preceding character
+// is matched to exclude hex and binary numbers and control commands.
+var symbolExpression = regexp.MustCompile(`(^|[^$%.\w])([A-Za-z_][A-Za-z0-9_]*)`)
+
+// registerNames contains the register names that can be part of an instruction operand.
+var registerNames = map[string]struct{}{
+	"A": {},
+	"X": {},
+	"Y": {},
+}
+
+// writeExports writes .export directives for all function labels and .import directives for
+// all referenced symbols that are not defined in the program. Labels that are defined in a bank
+// scope are exported by assigning the scoped label to a global symbol of the same name.
+func (f FileWriter) writeExports(labelScopes map[string]string) error {
+	exports, imports := f.exportsAndImports()
+	if len(exports) == 0 && len(imports) == 0 {
+		return nil
+	}
+
+	for _, name := range exports {
+		export := name
+		if qualified := qualifiedName(name, labelScopes); qualified != name {
+			export = name + " := " + qualified
+		}
+		if _, err := fmt.Fprintf(f.mainWriter, ".export %s\n", export); err != nil {
+			return fmt.Errorf("writing export: %w", err)
+		}
+	}
+	for _, name := range imports {
+		if _, err := fmt.Fprintf(f.mainWriter, ".import %s\n", name); err != nil {
+			return fmt.Errorf("writing import: %w", err)
+		}
+	}
+
+	if _, err := fmt.Fprintln(f.mainWriter); err != nil {
+		return fmt.Errorf("writing line: %w", err)
+	}
+	return nil
+}
+
+// exportsAndImports returns the sorted names of all function labels to export and all
+// referenced but undefined symbols to import.
+func (f FileWriter) exportsAndImports() ([]string, []string) {
+	defined := map[string]struct{}{}
+	for name := range f.app.Constants {
+		defined[name] = struct{}{}
+	}
+	for name := range f.app.Variables {
+		defined[name] = struct{}{}
+	}
+
+	var exports []string
+	for _, bank := range f.app.PRG {
+		for name := range bank.Constants {
+			defined[name] = struct{}{}
+		}
+		for name := range bank.Variables {
+			defined[name] = struct{}{}
+		}
+
+		for _, offset := range bank.Offsets {
+			if offset.Label == "" {
+				continue
+			}
+			defined[offset.Label] = struct{}{}
+			if offset.IsType(program.CallDestination) {
+				exports = append(exports, offset.Label)
+			}
+		}
+	}
+
+	referenced := map[string]struct{}{}
+	for _, bank := range f.app.PRG {
+		for _, offset := range bank.Offsets {
+			for _, name := range referencedSymbols(offset.Code) {
+				if _, ok := defined[name]; !ok {
+					referenced[name] = struct{}{}
+				}
+			}
+		}
+	}
+
+	imports := make([]string, 0, len(referenced))
+	for name := range referenced {
+		imports = append(imports, name)
+	}
+
+	slices.Sort(exports)
+	exports = slices.Compact(exports)
+	slices.Sort(imports)
+	return exports, imports
+}
+
+// referencedSymbols returns all symbol names that are referenced by the operand of the code line.
+func referencedSymbols(code string) []string {
+	var symbols []string
+	for _, position := range symbolPositions(code) {
+		symbols = append(symbols, code[position[0]:position[1]])
+	}
+	return symbols
+}
+
+// symbolPositions returns the start and end positions of all symbol names that are referenced
+// by the operand of the code line.
+func symbolPositions(code string) [][2]int {
+	operandStart := strings.IndexByte(code, ' ')
+	if operandStart < 0 {
+		return nil
+	}
+	operand := code[operandStart:]
+
+	var positions [][2]int
+	for _, match := range symbolExpression.FindAllStringSubmatchIndex(operand, -1) {
+		start, end := match[4], match[5]
+		if end < len(operand) && operand[end] == ':' {
+			continue // address size prefix
+		}
+		if _, ok := registerNames[operand[start:end]]; ok {
+			continue
+		}
+		positions = append(positions, [2]int{operandStart + start, operandStart + end})
+	}
+	return positions
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/retroenv/nesgodisasm/internal/assembler"
 	"github.com/retroenv/nesgodisasm/internal/program"
 )
 
@@ -62,3 +64,19 @@ func AssembleUsingExternalApp(asmFile, objectFile, outputFile string, conf Confi
 
 	return nil
 }
+
+// Makefile returns the content of a Makefile that assembles and links the asm file to a .nes
+// ROM using the given linker config file.
+func Makefile(asmFile, configFile, romFile string) string {
+	objectFile := strings.TrimSuffix(asmFile, filepath.Ext(asmFile)) + ".o"
+
+	return assembler.Makefile(assembler.MakefileRule{
+		Target:       romFile,
+		Dependencies: []string{asmFile, configFile},
+		Commands: []string{
+			fmt.Sprintf("%s %s -o %s", assemblerName, asmFile, objectFile),
+			fmt.Sprintf("%s -C %s -o %s %s", linkerName, configFile, romFile, objectFile),
+		},
+		Intermediates: []string{objectFile},
+	})
+}
@@ -0,0 +1,17 @@
+package ca65
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/retroenv/retrogolib/assert"
+)
+
+func TestMakefile(t *testing.T) {
+	makefile := Makefile("game.asm", "game.cfg", "game.nes")
+
+	assert.True(t, strings.Contains(makefile, "game.nes: game.asm game.cfg\n"))
+	assert.True(t, strings.Contains(makefile, "\tca65 game.asm -o game.o\n"))
+	assert.True(t, strings.Contains(makefile, "\tld65 -C game.cfg -o game.nes game.o\n"))
+	assert.True(t, strings.Contains(makefile, "\trm -f game.o\n"))
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/retroenv/nesgodisasm/internal/options"
 	"github.com/retroenv/nesgodisasm/internal/program"
 	"github.com/retroenv/nesgodisasm/internal/writer"
-	"github.com/retroenv/retrogolib/arch/nes/cartridge"
 )
 
 var cpuSelector = `.setcpu "6502x"` // allow unofficial opcodes
@@ -19,6 +18,10 @@ var headerByte = ".byte $%02x %-22s ; %s\n"
 
 var vectors = ".addr %s, %s, %s\n"
 
+// headerFileName is the name of the file that contains the iNES header if the header is split
+// from the main file.
+const headerFileName = "header.inc"
+
 // FileWriter writes the assembly file content.
 type FileWriter struct {
 	app           *program.Program
@@ -49,7 +52,18 @@ type lineWrite string
 // nolint: ireturn
 func New(app *program.Program, options options.Disassembler, mainWriter io.Writer, newBankWriter assembler.NewBankWriter) writer.AssemblerWriter {
 	opts := writer.Options{
-		OffsetComments: options.OffsetComments,
+		AddressPrefix:        options.AddressPrefix,
+		AliasDecimal:         options.AliasDecimal,
+		BankChecksums:        options.BankChecksums,
+		CanonicalOperands:    options.CanonicalOperands,
+		GroupDataByReference: options.GroupDataByReference,
+		HexdumpData:          options.HexdumpData,
+		MaxDataRun:           options.MaxDataRun,
+		MaxLineLength:        options.MaxLineLength,
+		OffsetComments:       options.OffsetComments,
+		Provenance:           options.Provenance,
+		SourceFile:           options.SourceFile,
+		SymbolicOffsets:      options.SymbolicOffsets,
 	}
 	return FileWriter{
 		app:           app,
@@ -61,37 +75,134 @@ func New(app *program.Program, options options.Disassembler, mainWriter io.Write
 }
 
 // Write writes the assembly file content including header, footer, code and data.
-// nolint:funlen, cyclop
 func (f FileWriter) Write() error {
-	control1, control2 := cartridge.ControlBytes(f.app.Battery, byte(f.app.Mirror), f.app.Mapper, len(f.app.Trainer) > 0)
-
 	var writes []any // nolint:prealloc
 
 	if !f.options.CodeOnly {
 		writes = []any{
 			customWrite(f.writer.WriteCommentHeader),
 			lineWrite(cpuSelector),
-			segmentWrite{name: "HEADER"},
-			lineWrite(iNESHeader),
-			headerByteWrite{value: byte(f.app.PrgSize() / 16384), comment: "Number of 16KB PRG-ROM banks"},
-			headerByteWrite{value: byte(len(f.app.CHR) / 8192), comment: "Number of 8KB CHR-ROM banks"},
-			headerByteWrite{value: control1, comment: "Control bits 1"},
-			headerByteWrite{value: control2, comment: "Control bits 2"},
-			headerByteWrite{value: f.app.RAM, comment: "Number of 8KB PRG-RAM banks"},
-			headerByteWrite{value: f.app.VideoFormat, comment: "Video format NTSC/PAL"},
 		}
+
+		header := f.headerWrites()
+		if f.options.SplitHeader {
+			writes = append(writes,
+				customWrite(func() error { return f.writeHeaderFile(header) }),
+				lineWrite(fmt.Sprintf(".include \"%s\"", headerFileName)),
+			)
+		} else {
+			writes = append(writes, header...)
+		}
+	}
+
+	if f.options.TableOfContents {
+		writes = append(writes, customWrite(f.writer.WriteTableOfContents))
+	}
+
+	var labelScopes map[string]string
+	if f.options.BankScopes {
+		labelScopes = f.bankLabelScopes()
 	}
 
-	for _, bank := range f.app.PRG {
+	if f.options.Exports {
+		writes = append(writes, customWrite(func() error { return f.writeExports(labelScopes) }))
+	}
+
+	if f.options.BankScopes {
+		for i, bank := range f.app.PRG {
+			writes = append(writes,
+				customWrite(func() error { return f.writeScopedBank(i, bank, labelScopes) }),
+			)
+		}
+	} else {
+		for _, bank := range f.app.PRG {
+			writes = append(writes,
+				prgBankWrite{bank: bank},
+			)
+		}
+	}
+
+	if !f.options.CodeOnly {
 		writes = append(writes,
-			prgBankWrite{bank: bank},
+			customWrite(f.writeCHR),
+			segmentWrite{name: "VECTORS"},
+			customWrite(func() error { return f.writeVectors(labelScopes) }),
 		)
 	}
 
-	if !f.options.CodeOnly {
-		writes = append(writes, customWrite(f.writeCHR), segmentWrite{name: "VECTORS"})
+	if f.options.XrefIndex {
+		writes = append(writes, customWrite(f.writer.WriteCrossReferenceIndex))
+	}
+
+	return f.processWrites(writes)
+}
+
+// writeVectors writes the addresses of the interrupt handlers, handlers that are defined in a
+// bank scope are qualified by the scope name.
+func (f FileWriter) writeVectors(labelScopes map[string]string) error {
+	handlers := f.app.Handlers
+	if _, err := fmt.Fprintf(f.mainWriter, vectors, qualifiedName(handlers.NMI, labelScopes),
+		qualifiedName(handlers.Reset, labelScopes), qualifiedName(handlers.IRQ, labelScopes)); err != nil {
+
+		return fmt.Errorf("writing vectors: %w", err)
+	}
+	return nil
+}
+
+// headerWrites returns the writes of the iNES header segment.
+func (f FileWriter) headerWrites() []any {
+	control1, control2 := f.app.ControlBytes()
+
+	writes := []any{
+		segmentWrite{name: "HEADER"},
+		lineWrite(iNESHeader),
+		headerByteWrite{value: byte(f.app.PrgSize() / 16384), comment: "Number of 16KB PRG-ROM banks"},
+		headerByteWrite{value: byte(len(f.app.CHR) / 8192), comment: "Number of 8KB CHR-ROM banks"},
+		headerByteWrite{value: control1, comment: "Control bits 1"},
+		headerByteWrite{value: control2, comment: "Control bits 2"},
+		headerByteWrite{value: f.app.RAM, comment: "Number of 8KB PRG-RAM banks"},
+		headerByteWrite{value: f.app.VideoFormat, comment: "Video format NTSC/PAL"},
+	}
+	if !f.app.NES2 {
+		return writes
+	}
+
+	writes[5] = headerByteWrite{value: control2, comment: "Control bits 2, " + f.app.ConsoleTypeName()}
+	writes[6] = headerByteWrite{value: f.app.MapperMSB, comment: "Mapper MSB and submapper"}
+	writes[7] = headerByteWrite{value: f.app.ROMSizeMSB, comment: "PRG-ROM and CHR-ROM size MSB"}
+	return append(writes,
+		headerByteWrite{value: f.app.RAMShifts[0], comment: "PRG-RAM size shift count"},
+		headerByteWrite{value: f.app.RAMShifts[1], comment: "CHR-RAM size shift count"},
+		headerByteWrite{value: f.app.Timing, comment: f.app.TimingName() + " timing"},
+		headerByteWrite{value: f.app.VsType, comment: "Vs. System PPU and hardware type"},
+		headerByteWrite{value: f.app.MiscROMs, comment: "Number of miscellaneous ROMs"},
+		headerByteWrite{value: f.app.ExpansionDevice, comment: "Default expansion device"},
+	)
+}
+
+// writeHeaderFile writes the iNES header segment to a separate file that gets included by the main file.
+func (f FileWriter) writeHeaderFile(header []any) error {
+	headerWriter, err := f.newBankWriter(headerFileName)
+	if err != nil {
+		return fmt.Errorf("creating header file: %w", err)
+	}
+
+	headerFile := f
+	headerFile.mainWriter = headerWriter
+	if err := headerFile.processWrites(header); err != nil {
+		_ = headerWriter.Close()
+		return err
 	}
 
+	if err := headerWriter.Close(); err != nil {
+		return fmt.Errorf("closing header file: %w", err)
+	}
+	return nil
+}
+
+// processWrites outputs all writes to the main writer.
+// nolint: cyclop
+func (f FileWriter) processWrites(writes []any) error {
 	for _, write := range writes {
 		switch t := write.(type) {
 		case headerByteWrite:
@@ -115,24 +226,23 @@ func (f FileWriter) Write() error {
 			}
 
 		case prgBankWrite:
-			if err := f.writeConstants(t.bank); err != nil {
-				return err
-			}
-			if err := f.writeVariables(t.bank); err != nil {
-				return err
-			}
-			if err := f.writeCode(t.bank); err != nil {
+			if err := f.writeBank(t.bank); err != nil {
 				return err
 			}
 		}
 	}
+	return nil
+}
 
-	if !f.options.CodeOnly {
-		if _, err := fmt.Fprintf(f.mainWriter, vectors, f.app.Handlers.NMI, f.app.Handlers.Reset, f.app.Handlers.IRQ); err != nil {
-			return fmt.Errorf("writing vectors: %w", err)
-		}
+// writeBank writes the constants, variables and code of a PRG bank to the output.
+func (f FileWriter) writeBank(bank *program.PRGBank) error {
+	if err := f.writeConstants(bank); err != nil {
+		return err
 	}
-	return nil
+	if err := f.writeVariables(bank); err != nil {
+		return err
+	}
+	return f.writeCode(bank)
 }
 
 // writeSegment writes a segment header to the output.
@@ -152,7 +262,7 @@ func (f FileWriter) writeSegment(name string) error {
 
 // writeConstants writes constant aliases to the output.
 func (f FileWriter) writeConstants(bank *program.PRGBank) error {
-	if err := f.writer.OutputAliasMap(bank.Constants); err != nil {
+	if err := f.writer.OutputGroupedAliasMap(bank.Constants, bank.ConstantGroups); err != nil {
 		return fmt.Errorf("writing constants output alias map: %w", err)
 	}
 	return nil
@@ -172,6 +282,19 @@ func (f FileWriter) writeCHR() error {
 		return err
 	}
 
+	if f.options.CHRSummary {
+		if err := f.writer.WriteCHRSummary(); err != nil {
+			return fmt.Errorf("writing CHR summary: %w", err)
+		}
+	}
+
+	if f.options.CHRFile != "" {
+		if err := f.writer.WriteCHRFile(f.newBankWriter, f.options.CHRFile); err != nil {
+			return fmt.Errorf("writing CHR data: %w", err)
+		}
+		return nil
+	}
+
 	if f.options.ZeroBytes {
 		if err := f.writer.BundleDataWrites(f.app.CHR, nil); err != nil {
 			return fmt.Errorf("writing CHR data: %w", err)
@@ -1,6 +1,9 @@
 package ca65
 
-import "github.com/retroenv/retrogolib/arch/nes/parameter"
+import (
+	"github.com/retroenv/nesgodisasm/internal/assembler"
+	"github.com/retroenv/retrogolib/arch/nes/parameter"
+)
 
 // ParamConfig configures the instruction parameter string converter.
 var ParamConfig = parameter.Config{
@@ -9,3 +12,10 @@ var ParamConfig = parameter.Config{
 	IndirectPrefix: "(",
 	IndirectSuffix: ")",
 }
+
+// Syntax adapts the operand formatting to the assembler.
+var Syntax = assembler.Syntax{
+	Params:   ParamConfig,
+	LowByte:  "<%s",
+	HighByte: ">%s",
+}
@@ -0,0 +1,88 @@
+package ca65
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+const bankScopeNaming = "bank%d"
+
+// bankLabelScopes returns a map of all labels to the name of the scope of the bank that defines them.
+func (f FileWriter) bankLabelScopes() map[string]string {
+	scopes := map[string]string{}
+	for i, bank := range f.app.PRG {
+		scope := fmt.Sprintf(bankScopeNaming, i)
+		for _, offset := range bank.Offsets {
+			if offset.Label != "" {
+				scopes[offset.Label] = scope
+			}
+		}
+	}
+	return scopes
+}
+
+// qualifiedName returns the name qualified by the scope of the bank that defines it, names that
+// are not defined in a bank scope are returned unchanged.
+func qualifiedName(name string, labelScopes map[string]string) string {
+	if scope, ok := labelScopes[name]; ok {
+		return scope + "::" + name
+	}
+	return name
+}
+
+// scopedBank returns a copy of the bank with all references to labels of other banks qualified
+// by the scope of the bank that defines them.
+func scopedBank(bank *program.PRGBank, scope string, labelScopes map[string]string) *program.PRGBank {
+	scoped := *bank
+	scoped.Offsets = make([]program.Offset, len(bank.Offsets))
+
+	for i, offset := range bank.Offsets {
+		offset.Code = qualifyReferences(offset.Code, scope, labelScopes)
+		scoped.Offsets[i] = offset
+	}
+	return &scoped
+}
+
+// qualifyReferences prefixes all symbols referenced by the code line that are labels of a different
+// scope with the name of their scope.
+func qualifyReferences(code, scope string, labelScopes map[string]string) string {
+	positions := symbolPositions(code)
+	if len(positions) == 0 {
+		return code
+	}
+
+	var buf strings.Builder
+	var last int
+	for _, position := range positions {
+		name := code[position[0]:position[1]]
+		labelScope, ok := labelScopes[name]
+		if !ok || labelScope == scope {
+			continue
+		}
+
+		buf.WriteString(code[last:position[0]])
+		buf.WriteString(labelScope + "::" + name)
+		last = position[1]
+	}
+	buf.WriteString(code[last:])
+	return buf.String()
+}
+
+// writeScopedBank writes the bank wrapped in a scope to avoid symbol name collisions between banks.
+func (f FileWriter) writeScopedBank(index int, bank *program.PRGBank, labelScopes map[string]string) error {
+	scope := fmt.Sprintf(bankScopeNaming, index)
+	if _, err := fmt.Fprintf(f.mainWriter, "\n.scope %s\n", scope); err != nil {
+		return fmt.Errorf("writing scope start: %w", err)
+	}
+
+	if err := f.writeBank(scopedBank(bank, scope, labelScopes)); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(f.mainWriter, ".endscope"); err != nil {
+		return fmt.Errorf("writing scope end: %w", err)
+	}
+	return nil
+}
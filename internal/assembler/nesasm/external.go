@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/retroenv/nesgodisasm/internal/assembler"
 )
 
 const assemblerName = "nesasm"
@@ -23,3 +25,12 @@ func AssembleUsingExternalApp(asmFile, outputFile string) error {
 
 	return nil
 }
+
+// Makefile returns the content of a Makefile that assembles the asm file to a .nes ROM.
+func Makefile(asmFile, romFile string) string {
+	return assembler.Makefile(assembler.MakefileRule{
+		Target:       romFile,
+		Dependencies: []string{asmFile},
+		Commands:     []string{fmt.Sprintf("%s -z -o %s %s", assemblerName, romFile, asmFile)},
+	})
+}
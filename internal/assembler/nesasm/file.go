@@ -35,8 +35,19 @@ type lineWrite string
 // nolint: ireturn
 func New(app *program.Program, options options.Disassembler, mainWriter io.Writer, newBankWriter assembler.NewBankWriter) writer.AssemblerWriter {
 	opts := writer.Options{
-		DirectivePrefix: " ",
-		OffsetComments:  options.OffsetComments,
+		DirectivePrefix:      " ",
+		AddressPrefix:        options.AddressPrefix,
+		AliasDecimal:         options.AliasDecimal,
+		BankChecksums:        options.BankChecksums,
+		CanonicalOperands:    options.CanonicalOperands,
+		GroupDataByReference: options.GroupDataByReference,
+		HexdumpData:          options.HexdumpData,
+		MaxDataRun:           options.MaxDataRun,
+		MaxLineLength:        options.MaxLineLength,
+		OffsetComments:       options.OffsetComments,
+		Provenance:           options.Provenance,
+		SourceFile:           options.SourceFile,
+		SymbolicOffsets:      options.SymbolicOffsets,
 	}
 	return FileWriter{
 		app:           app,
@@ -58,6 +69,10 @@ func (f FileWriter) Write() error {
 		}
 	}
 
+	if f.options.TableOfContents {
+		writes = append(writes, customWrite(f.writer.WriteTableOfContents))
+	}
+
 	nextBank := addPrgBankSelectors(int(f.app.CodeBaseAddress), f.app.PRG)
 	for _, bank := range f.app.PRG {
 		writes = append(writes,
@@ -70,6 +85,10 @@ func (f FileWriter) Write() error {
 		customWrite(f.writeCHR(nextBank)),
 	)
 
+	if f.options.XrefIndex {
+		writes = append(writes, customWrite(f.writer.WriteCrossReferenceIndex))
+	}
+
 	for _, write := range writes {
 		switch t := write.(type) {
 		case lineWrite:
@@ -118,7 +137,7 @@ func (f FileWriter) writeROMHeader() error {
 
 // writeConstants writes constant aliases to the output.
 func (f FileWriter) writeConstants(bank *program.PRGBank) error {
-	if err := f.writer.OutputAliasMap(bank.Constants); err != nil {
+	if err := f.writer.OutputGroupedAliasMap(bank.Constants, bank.ConstantGroups); err != nil {
 		return fmt.Errorf("writing constants output alias map: %w", err)
 	}
 	return nil
@@ -135,6 +154,12 @@ func (f FileWriter) writeVariables(bank *program.PRGBank) error {
 // writeCHR writes the CHR content to the output.
 func (f FileWriter) writeCHR(nextBank int) func() error {
 	return func() error {
+		if f.options.CHRSummary {
+			if err := f.writer.WriteCHRSummary(); err != nil {
+				return fmt.Errorf("writing CHR summary: %w", err)
+			}
+		}
+
 		if _, err := fmt.Fprint(f.mainWriter, "\n .DATA"); err != nil {
 			return fmt.Errorf("writing CHR bank: %w", err)
 		}
@@ -0,0 +1,46 @@
+package assembler
+
+import (
+	"fmt"
+
+	"github.com/retroenv/retrogolib/arch/nes/parameter"
+)
+
+// Syntax adapts the operand formatting to the syntax of an assembler.
+type Syntax struct {
+	Params   parameter.Config // configures the instruction parameter string converter
+	LowByte  string           // format of the operator that selects the low byte of an expression
+	HighByte string           // format of the operator that selects the high byte of an expression
+}
+
+// CanonicalSyntax is the assembler neutral operand syntax. It does not use any address size
+// prefixes, uses parentheses for indirect addressing and < and > to select the low and high
+// byte of an expression.
+var CanonicalSyntax = Syntax{
+	Params: parameter.Config{
+		IndirectPrefix: "(",
+		IndirectSuffix: ")",
+	},
+	LowByte:  "<%s",
+	HighByte: ">%s",
+}
+
+// CanonicalOperandsDescription describes the canonical operand syntax in the comment header.
+const CanonicalOperandsDescription = "Operands use the canonical syntax: no address size prefixes, " +
+	"zero page addresses have 2 hex digits, indirect addressing uses (address), " +
+	"low and high bytes use <address and >address"
+
+// Converter returns the instruction parameter string converter of the syntax.
+func (s Syntax) Converter() parameter.Converter {
+	return parameter.New(s.Params)
+}
+
+// LowByteOf returns the operand that selects the low byte of the expression.
+func (s Syntax) LowByteOf(expression string) string {
+	return fmt.Sprintf(s.LowByte, expression)
+}
+
+// HighByteOf returns the operand that selects the high byte of the expression.
+func (s Syntax) HighByteOf(expression string) string {
+	return fmt.Sprintf(s.HighByte, expression)
+}
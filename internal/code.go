@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 
+	"github.com/retroenv/nesgodisasm/internal/arch"
 	"github.com/retroenv/nesgodisasm/internal/program"
 )
 
@@ -27,14 +28,7 @@ func (dis *Disasm) processJumpDestinations() {
 
 		name := offsetInfo.Label
 		if name == "" {
-			switch {
-			case offsetInfo.IsType(program.JumpEngine):
-				name = fmt.Sprintf(jumpEngineNaming, address)
-			case offsetInfo.IsType(program.CallDestination):
-				name = fmt.Sprintf(funcNaming, address)
-			default:
-				name = fmt.Sprintf(labelNaming, address)
-			}
+			name = autoLabelName(address, offsetInfo)
 			offsetInfo.Label = name
 		}
 
@@ -58,6 +52,18 @@ func (dis *Disasm) processJumpDestinations() {
 	}
 }
 
+// autoLabelName returns the generated label name of the jump destination at the given address.
+func autoLabelName(address uint16, offsetInfo *arch.Offset) string {
+	switch {
+	case offsetInfo.IsType(program.JumpEngine):
+		return fmt.Sprintf(jumpEngineNaming, address)
+	case offsetInfo.IsType(program.CallDestination):
+		return fmt.Sprintf(funcNaming, address)
+	default:
+		return fmt.Sprintf(labelNaming, address)
+	}
+}
+
 // handleJumpIntoInstruction converts an instruction that has a jump destination label inside
 // its second or third opcode bytes into data.
 func (dis *Disasm) handleJumpIntoInstruction(address uint16) {
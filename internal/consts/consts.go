@@ -106,9 +106,11 @@ func (c *Consts) SetToProgram(app *program.Program) {
 		constantInfo := c.constants[address]
 		if constantInfo.Read != "" {
 			app.Constants[constantInfo.Read] = address
+			app.ConstantGroups[constantInfo.Read] = constantInfo.Group
 		}
 		if constantInfo.Write != "" {
 			app.Constants[constantInfo.Write] = address
+			app.ConstantGroups[constantInfo.Write] = constantInfo.Group
 		}
 	}
 }
@@ -120,9 +122,11 @@ func (c *Consts) SetBankConstants(bankID int, prgBank *program.PRGBank) {
 		constantInfo := bank.constants[address]
 		if constantInfo.Read != "" {
 			prgBank.Constants[constantInfo.Read] = address
+			prgBank.ConstantGroups[constantInfo.Read] = constantInfo.Group
 		}
 		if constantInfo.Write != "" {
 			prgBank.Constants[constantInfo.Write] = address
+			prgBank.ConstantGroups[constantInfo.Write] = constantInfo.Group
 		}
 	}
 }
@@ -0,0 +1,88 @@
+// Package csvexport provides exporting of the offsets of a disassembled program as CSV file.
+package csvexport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// header contains the column names of the exported CSV file.
+var header = []string{"bank", "address", "type", "label", "code", "comment"}
+
+// Offset type names of the type column.
+const (
+	typeCode              = "code"
+	typeCodeAsData        = "code_as_data"
+	typeData              = "data"
+	typeFunctionReference = "function_reference"
+)
+
+// Write writes all offsets of the program as CSV rows to the writer. The first row contains the
+// column names, every following row describes one instruction or data byte of a PRG bank.
+// Offsets of the operand bytes of an instruction are skipped as they are part of the instruction.
+func Write(app *program.Program, writer io.Writer) error {
+	w := csv.NewWriter(writer)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+
+	for i, bank := range app.PRG {
+		bankIndex := strconv.Itoa(i)
+
+		for _, offset := range bank.Offsets {
+			if len(offset.Data) == 0 && offset.Label == "" {
+				continue
+			}
+
+			row := []string{
+				bankIndex,
+				fmt.Sprintf("$%04X", offset.Address),
+				typeName(offset),
+				offset.Label,
+				code(offset),
+				offset.Comment,
+			}
+			if err := w.Write(row); err != nil {
+				return fmt.Errorf("writing row: %w", err)
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("writing rows: %w", err)
+	}
+	return nil
+}
+
+// typeName returns the name of the offset type for the type column.
+func typeName(offset program.Offset) string {
+	switch {
+	case offset.IsType(program.CodeAsData):
+		return typeCodeAsData
+	case offset.IsType(program.CodeOffset):
+		return typeCode
+	case offset.IsType(program.FunctionReference):
+		return typeFunctionReference
+	default:
+		return typeData
+	}
+}
+
+// code returns the code of the offset, data bytes without code are output as byte directive.
+func code(offset program.Offset) string {
+	if offset.Code != "" || len(offset.Data) == 0 {
+		return offset.Code
+	}
+
+	values := make([]string, len(offset.Data))
+	for i, b := range offset.Data {
+		values[i] = fmt.Sprintf("$%02X", b)
+	}
+	return ".byte " + strings.Join(values, ", ")
+}
@@ -0,0 +1,40 @@
+package csvexport
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/nes/cartridge"
+	"github.com/retroenv/retrogolib/assert"
+)
+
+func TestWrite(t *testing.T) {
+	app := program.New(cartridge.New())
+	bank := program.NewPRGBank(8)
+	bank.Offsets[0] = program.Offset{Data: []byte{0x20, 0x05, 0x80}, Type: program.CodeOffset,
+		Address: 0x8000, Label: "Reset", Code: "jsr _func_8005", Comment: "$8000  20 05 80"}
+	bank.Offsets[1] = program.Offset{Type: program.CodeOffset, Address: 0x8001}
+	bank.Offsets[2] = program.Offset{Type: program.CodeOffset, Address: 0x8002}
+	bank.Offsets[3] = program.Offset{Data: []byte{0x40}, Type: program.CodeOffset,
+		Address: 0x8003, Code: "rti", Comment: "$8003  40"}
+	bank.Offsets[4] = program.Offset{Data: []byte{0xea}, Type: program.DataOffset, Address: 0x8004}
+	bank.Offsets[5] = program.Offset{Data: []byte{0x60}, Type: program.CodeOffset,
+		Address: 0x8005, Label: "_func_8005", Code: "rts", Comment: "$8005  60"}
+	bank.Offsets[6] = program.Offset{Data: []byte{0x04, 0x00}, Type: program.CodeAsData | program.DataOffset,
+		Address: 0x8006, Comment: "disambiguous instruction: nop $00"}
+	bank.Offsets[7] = program.Offset{Type: program.CodeAsData | program.DataOffset, Address: 0x8007}
+	app.PRG = []*program.PRGBank{bank}
+
+	expected := `bank,address,type,label,code,comment
+0,$8000,code,Reset,jsr _func_8005,$8000  20 05 80
+0,$8003,code,,rti,$8003  40
+0,$8004,data,,.byte $EA,
+0,$8005,code,_func_8005,rts,$8005  60
+0,$8006,code_as_data,,".byte $04, $00",disambiguous instruction: nop $00
+`
+
+	var buffer bytes.Buffer
+	assert.NoError(t, Write(app, &buffer))
+	assert.Equal(t, expected, buffer.String())
+}
@@ -2,9 +2,12 @@
 package disasm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
+	"maps"
 
 	"github.com/retroenv/nesgodisasm/internal/arch"
 	"github.com/retroenv/nesgodisasm/internal/assembler"
@@ -44,7 +47,8 @@ type Disasm struct {
 	jumpEngine arch.JumpEngine
 	vars       arch.VariableManager
 
-	branchDestinations map[uint16]struct{} // set of all addresses that are branched to
+	branchDestinations map[uint16]struct{}  // set of all addresses that are branched to
+	warnings           map[arch.Warning]int // number of suspicious results found per warning kind
 
 	// TODO handle bank switch
 	offsetsToParse      []uint16
@@ -54,6 +58,8 @@ type Disasm struct {
 	functionReturnsToParse      []uint16
 	functionReturnsToParseAdded map[uint16]struct{}
 
+	unreachableToParse []unreachableAddress // addresses that are only parsed if they turn out to be reachable
+
 	mapper *mapper.Mapper
 }
 
@@ -61,6 +67,10 @@ type Disasm struct {
 func New(ar arch.Architecture, logger *log.Logger, cart *cartridge.Cartridge,
 	options options.Disassembler, fileWriterConstructor FileWriterConstructor) (*Disasm, error) {
 
+	if len(cart.PRG) == 0 {
+		return nil, errors.New("cartridge does not contain any PRG-ROM banks")
+	}
+
 	dis := &Disasm{
 		arch:                        ar,
 		logger:                      logger,
@@ -69,6 +79,7 @@ func New(ar arch.Architecture, logger *log.Logger, cart *cartridge.Cartridge,
 		vars:                        vars.New(ar),
 		fileWriterConstructor:       fileWriterConstructor,
 		branchDestinations:          map[uint16]struct{}{},
+		warnings:                    map[arch.Warning]int{},
 		offsetsToParseAdded:         map[uint16]struct{}{},
 		offsetsParsed:               map[uint16]struct{}{},
 		functionReturnsToParseAdded: map[uint16]struct{}{},
@@ -94,32 +105,92 @@ func New(ar arch.Architecture, logger *log.Logger, cart *cartridge.Cartridge,
 			return nil, err
 		}
 	}
+	if err := dis.applyWordTables(); err != nil {
+		return nil, err
+	}
 
 	return dis, nil
 }
 
-// Process disassembles the cartridge.
-func (dis *Disasm) Process(mainWriter io.Writer, newBankWriter assembler.NewBankWriter) (*program.Program, error) {
-	if err := dis.followExecutionFlow(); err != nil {
+// Process disassembles the cartridge and writes the assembly output.
+func (dis *Disasm) Process(ctx context.Context, mainWriter io.Writer,
+	newBankWriter assembler.NewBankWriter) (*program.Program, error) {
+
+	app, err := dis.Disassemble(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if dis.options.LineEndings == options.LineEndingsCRLF {
+		mainWriter, newBankWriter = crlfWriters(mainWriter, newBankWriter, dis.options.CHRFile)
+	}
+
+	fileWriter := dis.fileWriterConstructor(app, dis.options, mainWriter, newBankWriter)
+	if err = fileWriter.Write(); err != nil {
+		return nil, fmt.Errorf("writing app to file: %w", err)
+	}
+	return app, nil
+}
+
+// Disassemble disassembles the cartridge and returns the program without writing any output.
+// The disassembly gets aborted with the context error if the context is cancelled.
+func (dis *Disasm) Disassemble(ctx context.Context) (*program.Program, error) {
+	if err := dis.followExecutionFlow(ctx); err != nil {
 		return nil, err
 	}
 
+	dis.arch.PostProcess(dis)
 	dis.mapper.ProcessData()
 	if err := dis.vars.Process(dis); err != nil {
 		return nil, fmt.Errorf("processing variables: %w", err)
 	}
 	dis.constants.Process()
+	if len(dis.options.KnownFunctions) > 0 {
+		dis.detectKnownFunctions()
+	}
 	dis.processJumpDestinations()
+	if dis.options.CollapseIdenticalFunctions {
+		dis.detectIdenticalFunctions()
+	}
 
-	app, err := dis.convertToProgram()
-	if err != nil {
-		return nil, err
+	return dis.convertToProgram()
+}
+
+// crlfWriters returns the main writer and the bank writer constructor wrapped to output all
+// line feeds as windows line endings. The binary file with the given name is not converted.
+func crlfWriters(mainWriter io.Writer, newBankWriter assembler.NewBankWriter,
+	binaryFile string) (io.Writer, assembler.NewBankWriter) {
+
+	mainWriter = writer.NewLineEndingWriter(mainWriter, "\r\n")
+	if newBankWriter == nil {
+		return mainWriter, nil
 	}
-	fileWriter := dis.fileWriterConstructor(app, dis.options, mainWriter, newBankWriter)
-	if err = fileWriter.Write(); err != nil {
-		return nil, fmt.Errorf("writing app to file: %w", err)
+
+	return mainWriter, func(baseName string) (io.WriteCloser, error) {
+		bankWriter, err := newBankWriter(baseName)
+		if err != nil || baseName == binaryFile {
+			return bankWriter, err
+		}
+		return writer.NewLineEndingWriteCloser(bankWriter, "\r\n"), nil
 	}
-	return app, nil
+}
+
+// Coverage returns the classification statistics of all PRG bytes,
+// it has to be called after the cartridge has been disassembled.
+func (dis *Disasm) Coverage() mapper.Coverage {
+	return dis.mapper.Coverage(dis)
+}
+
+// OpcodeHistogram returns the number of decoded instructions per opcode,
+// it has to be called after the cartridge has been disassembled.
+func (dis *Disasm) OpcodeHistogram() []mapper.OpcodeCount {
+	return dis.mapper.OpcodeHistogram()
+}
+
+// Nodes returns the control flow graph nodes of all decoded instructions,
+// it has to be called after the cartridge has been disassembled.
+func (dis *Disasm) Nodes() []mapper.Node {
+	return dis.mapper.Nodes(dis)
 }
 
 // Cart returns the loaded cartridge.
@@ -185,6 +256,8 @@ func (dis *Disasm) convertToProgram() (*program.Program, error) {
 	app.CodeBaseAddress = dis.codeBaseAddress
 	app.VectorsStartAddress = dis.vectorsStartAddress
 	app.Handlers = dis.handlers
+	app.SetNES2Header(dis.options.INESHeader)
+	renameHandlers(&app.Handlers, dis.options.Renames)
 
 	if err := dis.mapper.SetProgramBanks(dis, app); err != nil {
 		return nil, fmt.Errorf("setting program banks: %w", err)
@@ -192,6 +265,10 @@ func (dis *Disasm) convertToProgram() (*program.Program, error) {
 
 	dis.constants.SetToProgram(app)
 	dis.vars.SetToProgram(app)
+	renameAliases(app, dis.options.Renames)
+	if dis.options.NoAutoLabels {
+		dis.removeAutoLabels(app)
+	}
 
 	crc32q := crc32.MakeTable(crc32.IEEE)
 	app.Checksums.PRG = crc32.Checksum(dis.cart.PRG, crc32q)
@@ -201,6 +278,44 @@ func (dis *Disasm) convertToProgram() (*program.Program, error) {
 	return app, nil
 }
 
+// renameHandlers applies the rename map to the label names of the interrupt handlers.
+func renameHandlers(handlers *program.Handlers, renames map[string]string) {
+	for _, handler := range []*string{&handlers.NMI, &handlers.Reset, &handlers.IRQ} {
+		if name, ok := renames[*handler]; ok {
+			*handler = name
+		}
+	}
+}
+
+// renameAliases applies the rename map to the names of the constants and variables of the program
+// and all its banks, the references to them are renamed when the banks are set.
+func renameAliases(app *program.Program, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+
+	renameAliasMap(app.Constants, renames)
+	renameAliasMap(app.ConstantGroups, renames)
+	renameAliasMap(app.Variables, renames)
+	for _, bank := range app.PRG {
+		renameAliasMap(bank.Constants, renames)
+		renameAliasMap(bank.ConstantGroups, renames)
+		renameAliasMap(bank.Variables, renames)
+	}
+}
+
+// renameAliasMap renames all keys of the alias map that are contained in the rename map.
+func renameAliasMap[T any](aliases map[string]T, renames map[string]string) {
+	renamed := map[string]T{}
+	for name, value := range aliases {
+		if newName, ok := renames[name]; ok {
+			renamed[newName] = value
+			delete(aliases, name)
+		}
+	}
+	maps.Copy(aliases, renamed)
+}
+
 func (dis *Disasm) loadCodeDataLog() error {
 	prgFlags, err := codedatalog.LoadFile(dis.cart, dis.options.CodeDataLog)
 	if err != nil {
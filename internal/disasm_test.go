@@ -3,16 +3,25 @@ package disasm
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"io"
 	"strings"
 	"testing"
 
 	"github.com/retroenv/nesgodisasm/internal/arch/m6502"
 	"github.com/retroenv/nesgodisasm/internal/assembler"
+	"github.com/retroenv/nesgodisasm/internal/assembler/asm6"
 	"github.com/retroenv/nesgodisasm/internal/assembler/ca65"
+	"github.com/retroenv/nesgodisasm/internal/assembler/nesasm"
+	"github.com/retroenv/nesgodisasm/internal/mapper"
 	"github.com/retroenv/nesgodisasm/internal/options"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/nesgodisasm/internal/symbols"
 	"github.com/retroenv/retrogolib/arch/nes/cartridge"
-	"github.com/retroenv/retrogolib/arch/nes/parameter"
+	"github.com/retroenv/retrogolib/arch/nes/codedatalog"
 	"github.com/retroenv/retrogolib/assert"
 	"github.com/retroenv/retrogolib/log"
 )
@@ -89,6 +98,70 @@ func TestDisasmReferencingUnofficialInstruction(t *testing.T) {
 	runDisasm(t, nil, input, expected)
 }
 
+func TestDisasmGroupDataByReference(t *testing.T) {
+	input := []byte{
+		0xbd, 0x08, 0x80, // lda $8008,X
+		0xbd, 0x09, 0x80, // lda $8009,X
+		0x90, 0x02, // bcc $800a
+		0x82, 0x04, // unofficial nop instruction: nop #$04
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda a:_data_8008_indexed,X
+        lda a:_data_8009_indexed,X
+        bcc _label_800a
+        
+        _data_8008_indexed:
+        .byte $82                        ; disambiguous instruction: nop #$04
+        
+        _data_8009_indexed:
+        .byte $04
+        
+        _label_800a:
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.GroupDataByReference = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmGroupDataByReferenceAdjacentTables(t *testing.T) {
+	input := []byte{
+		0xbd, 0x08, 0x80, // lda $8008,X
+		0xbd, 0x09, 0x80, // lda $8009,X
+		0x90, 0x03, // bcc $800b
+		0x1c, 0x11, 0x22, // unofficial nop instruction: nop $2211,X
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda a:_data_8008_indexed,X
+        lda a:_data_8009_indexed,X
+        bcc _label_800b
+        
+        _data_8008_indexed:
+        .byte $1c                        ; disambiguous instruction: nop a:$2211,X
+        
+        _data_8009_indexed:
+        .byte $11, $22
+        
+        _label_800b:
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.GroupDataByReference = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
 func TestDisasmJumpEngineTableFromCaller(t *testing.T) {
 	input := []byte{
 		0x20, 0x05, 0x80, // jsr $8005
@@ -180,6 +253,187 @@ func TestDisasmJumpEngineTableAppended(t *testing.T) {
 	runDisasm(t, nil, input, expected)
 }
 
+func TestDisasmJumpEngineIndexY(t *testing.T) {
+	input := []byte{
+		0xa5, 0xd7, // lda z:$D7
+		0x0a,             // asl a
+		0xa8,             // tay
+		0xb9, 0x1a, 0x80, // lda a:$801A,Y
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xc8,             // iny
+		0xb9, 0x1a, 0x80, // lda a:$801A,Y
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0xad, 0x19, 0x80, // lda a:$8019
+		0x85, 0x10, // sta z:$10
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x07,
+		0x1c, 0x80, // .word $801C
+		0x40, // rti
+	}
+
+	expected := `
+        _var_0200 = $0200
+
+        Reset:                           ; jump engine detected
+        lda z:$D7
+        asl a
+        tay
+        lda a:_jump_table_801a,Y
+        sta a:_var_0200
+        iny
+        lda a:_jump_table_801a,Y
+        sta a:$0201
+        lda a:_data_8019
+        sta z:$10
+        jmp (_var_0200)
+
+        _data_8019:
+        .byte $07
+
+        _jump_table_801a:
+        .word _label_801c
+
+        _label_801c:
+        rti
+`
+
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmJumpEngineTableBoundsCheck(t *testing.T) {
+	input := []byte{
+		0xa5, 0xd7, // lda z:$D7
+		0xc9, 0x04, // cmp #$04
+		0xb0, 0x1b, // bcs $8021
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x17, 0x80, // lda a:$8017,X
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xbd, 0x18, 0x80, // lda a:$8018,X
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x22, 0x80, // .word $8022
+		0x23, 0x80, // .word $8023
+		0x24, 0x80, // .word $8024
+		0x25, 0x80, // .word $8025
+		0x27, 0x80, // not part of the table
+		0x40, // rti
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
+	}
+
+	expected := `
+		_var_0200 = $0200
+
+        Reset:                           ; jump engine detected
+        lda z:$D7
+        cmp #$04
+        bcs _label_8021
+        asl a
+        tax
+        lda a:_jump_table_8017,X
+        sta a:_var_0200
+        lda a:_jump_table_8017+1,X
+        sta a:$0201
+        jmp (_var_0200)
+
+        _jump_table_8017:
+        .word _label_8022
+        .word _label_8023
+        .word _label_8024
+        .word _label_8025
+        .byte $27, $80
+
+        _label_8021:
+        rti
+
+        _label_8022:
+        rts
+
+        _label_8023:
+        rts
+
+        _label_8024:
+        rts
+
+        _label_8025:
+        rts
+`
+
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmJumpEngineTableBoundsCheckOtherRegister(t *testing.T) {
+	input := []byte{
+		0xa4, 0xd7, // ldy z:$D7
+		0xc0, 0x04, // cpy #$04
+		0xb0, 0x1b, // bcs $8021
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x17, 0x80, // lda a:$8017,X
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xbd, 0x18, 0x80, // lda a:$8018,X
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x22, 0x80, // .word $8022
+		0x23, 0x80, // .word $8023
+		0x24, 0x80, // .word $8024
+		0x25, 0x80, // .word $8025
+		0x26, 0x80, // .word $8026
+		0x40, // rti
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
+	}
+
+	expected := `
+        _var_0200 = $0200
+
+        Reset:                           ; jump engine detected
+        ldy z:$D7
+        cpy #$04
+        bcs _label_8021
+        asl a
+        tax
+        lda a:_jump_table_8017,X
+        sta a:_var_0200
+        lda a:_jump_table_8017+1,X
+        sta a:$0201
+        jmp (_var_0200)
+
+        _jump_table_8017:
+        .word _label_8022
+        .word _label_8023
+        .word _label_8024
+        .word _label_8025
+        .word _label_8026
+
+        _label_8021:
+        rti
+
+        _label_8022:
+        rts
+
+        _label_8023:
+        rts
+
+        _label_8024:
+        rts
+
+        _label_8025:
+        rts
+
+        _label_8026:
+        rts
+`
+
+	runDisasm(t, nil, input, expected)
+}
+
 // TODO detect jump engine in generated code
 func TestDisasmJumpEngineZeroPage(t *testing.T) {
 	input := []byte{
@@ -221,91 +475,3118 @@ func TestDisasmJumpEngineZeroPage(t *testing.T) {
 	runDisasm(t, nil, input, expected)
 }
 
-func TestDisasmMixedAccess(t *testing.T) {
+func TestDisasmJumpEngineSelfReference(t *testing.T) {
 	input := []byte{
-		0x85, 0x04, // sta $04
-		0xb1, 0x04, // lda $04,Y
-		0x40, // rti
+		0xa5, 0xd7, // lda z:$D7
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x13, 0x80, // lda a:$8013,X
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xbd, 0x14, 0x80, // lda a:$8014,X
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x1b, 0x80, // .word $801b
+		0x1c, 0x80, // .word $801c
+		0x17, 0x80, // self-referential pointer $8017
+		0xff, 0xff, // data
+		0x60, // rts
+		0x60, // rts
 	}
 
 	expected := `
-        _var_0004_indexed = $0004
-        
-        Reset:
-        sta z:_var_0004_indexed
-        lda (_var_0004_indexed),Y
-        rti
+        _var_0200 = $0200
+
+        Reset:                           ; jump engine detected
+        lda z:$D7
+        asl a
+        tax
+        lda a:_jump_table_8013,X
+        sta a:_var_0200
+        lda a:_jump_table_8013+1,X
+        sta a:$0201
+        jmp (_var_0200)
+
+        _jump_table_8013:
+        .word _label_801b
+        .word _label_801c
+        .byte $17, $80, $ff, $ff         ; suspicious self-reference
+
+        _label_801b:
+        rts
+
+        _label_801c:
+        rts
 `
 
-	runDisasm(t, nil, input, expected)
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
 }
 
-func TestDisasmDisambiguousInstructions(t *testing.T) {
+func TestDisasmJumpEngineSelfReferenceKeepsComment(t *testing.T) {
 	input := []byte{
-		0x4c, 0x05, 0x80, // jmp $8005
-		0x04, 0xa9, // nop $A9
-		0xea,       // nop
-		0x30, 0xFB, // bmi $03
-		0x30, 0xFA, // bmi $04
-		0x40, // rti
+		0xa5, 0xd7, // lda z:$D7
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x13, 0x80, // lda a:$8013,X
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xbd, 0x14, 0x80, // lda a:$8014,X
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x1b, 0x80, // .word $801b
+		0x1c, 0x80, // .word $801c
+		0x17, 0x80, // self-referential pointer $8017
+		0xff, 0xff, // data
+		0x60, // rts
+		0x60, // rts
 	}
 
-	expected := `Reset:
-        jmp _label_8005
-        
-        _label_8003:
-        .byte $04                        ; branch into instruction detected: disambiguous instruction: nop z:$A9
-        
-        _label_8004:
-        .byte $a9
-        
-        _label_8005:
-        nop
-        bmi _label_8003
-        bmi _label_8004
-        rti
-`
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.OffsetComments = false
+	opts.HexComments = false
+	disasm := testProgram(t, opts, cartridge.New(), input)
+	disasm.mapper.OffsetInfo(0x8017).Comment = "pointer list end"
 
-	runDisasm(t, nil, input, expected)
+	app, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "pointer list end  suspicious self-reference", app.PRG[0].Offsets[0x17].Comment)
 }
 
-func TestDisasmDifferentCodeBaseAddress(t *testing.T) {
+func TestDisasmJumpEngineMinimumEntriesRejected(t *testing.T) {
 	input := []byte{
-		0x20, 0x68, 0xa2, // jsr a268
-		0xb9, 0xfe, 0xbf, // lda a:$bffe,Y
-		0x40, // rti
+		0xa5, 0xd7, // lda z:$D7
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x13, 0x80, // lda a:$8013,X
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xbd, 0x14, 0x80, // lda a:$8014,X
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x15, 0x80, // coincidental function reference $8015
+		0x60, // rts
 	}
 
 	expected := `
-        _var_bffe_indexed = $BFFE
-        
+        _var_0200 = $0200
+
         Reset:
-        jsr a:$A268                    ; $C000  20 68 A2
-        lda a:_var_bffe_indexed,Y      ; $C003  B9 FE BF
-        rti                            ; $C006  40
+        lda z:$D7
+        asl a
+        tax
+        lda a:_data_8013_indexed,X
+        sta a:_var_0200
+        lda a:_data_8014_indexed,X
+        sta a:$0201
+        jmp (_var_0200)
+
+        _data_8013_indexed:
+        .byte $15
+
+        _data_8014_indexed:
+        .byte $80, $60
 `
 
-	setup := func(_ *options.Disassembler, cart *cartridge.Cartridge) {
-		cart.PRG = make([]byte, 0x4000)
-		cart.PRG[0x3FFD] = 0xC0 // reset handler that forces base address to $C000
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.MinJumpTableEntries = 2
+		opts.OffsetComments = false
+		opts.HexComments = false
 	}
 	runDisasm(t, setup, input, expected)
 }
 
-func TestDisasmIndirectJmp(t *testing.T) {
+func TestDisasmJumpEngineMinimumEntriesAccepted(t *testing.T) {
 	input := []byte{
-		0x6c, 0xce, 0x20, // jmp ($20CE)
+		0xa5, 0xd7, // lda z:$D7
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x13, 0x80, // lda a:$8013,X
+		0x8d, 0x00, 0x02, // sta a:$0200
+		0xbd, 0x14, 0x80, // lda a:$8014,X
+		0x8d, 0x01, 0x02, // sta a:$0201
+		0x6c, 0x00, 0x02, // jmp ($0200)
+		0x1b, 0x80, // .word $801b
+		0x1c, 0x80, // .word $801c
+		0x1d, 0x80, // .word $801d
+		0x1e, 0x80, // .word $801e
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
+		0x60, // rts
 	}
 
-	expected := `Reset:                           ; jump engine detected
-        jmp ($20CE)                    ; $8000  6C CE 20
+	expected := `
+        _var_0200 = $0200
+
+        Reset:                           ; jump engine detected
+        lda z:$D7
+        asl a
+        tax
+        lda a:_jump_table_8013,X
+        sta a:_var_0200
+        lda a:_jump_table_8013+1,X
+        sta a:$0201
+        jmp (_var_0200)
+
+        _jump_table_8013:
+        .word _label_801b
+        .word _label_801c
+        .word _label_801d
+        .word _label_801e
+
+        _label_801b:
+        rts
+
+        _label_801c:
+        rts
+
+        _label_801d:
+        rts
+
+        _label_801e:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.MinJumpTableEntries = 4
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmMixedAccess(t *testing.T) {
+	input := []byte{
+		0x85, 0x04, // sta $04
+		0xb1, 0x04, // lda $04,Y
+		0x40, // rti
+	}
+
+	expected := `
+        _var_0004_indexed = $0004
+        
+        Reset:
+        sta z:_var_0004_indexed
+        lda (_var_0004_indexed),Y
+        rti
+`
+
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmDisambiguousInstructions(t *testing.T) {
+	input := []byte{
+		0x4c, 0x05, 0x80, // jmp $8005
+		0x04, 0xa9, // nop $A9
+		0xea,       // nop
+		0x30, 0xFB, // bmi $03
+		0x30, 0xFA, // bmi $04
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        jmp _label_8005
+        
+        _label_8003:
+        .byte $04                        ; branch into instruction detected: disambiguous instruction: nop z:$A9
+        
+        _label_8004:
+        .byte $a9
+        
+        _label_8005:
+        nop
+        bmi _label_8003
+        bmi _label_8004
+        rti
+`
+
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmDifferentCodeBaseAddress(t *testing.T) {
+	input := []byte{
+		0x20, 0x68, 0xa2, // jsr a268
+		0xb9, 0xfe, 0xbf, // lda a:$bffe,Y
+		0x40, // rti
+	}
+
+	expected := `
+        _var_bffe_indexed = $BFFE
+        
+        Reset:
+        jsr a:$A268                    ; $C000  20 68 A2
+        lda a:_var_bffe_indexed,Y      ; $C003  B9 FE BF
+        rti                            ; $C006  40
+`
+
+	setup := func(_ *options.Disassembler, cart *cartridge.Cartridge) {
+		cart.PRG = make([]byte, 0x4000)
+		cart.PRG[0x3FFD] = 0xC0 // reset handler that forces base address to $C000
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmIndirectJmp(t *testing.T) {
+	input := []byte{
+		0x6c, 0xce, 0x20, // jmp ($20CE)
+	}
+
+	expected := `Reset:                           ; jump engine detected
+        jmp ($20CE)                    ; $8000  6C CE 20
+`
+
+	setup := func(_ *options.Disassembler, _ *cartridge.Cartridge) {}
+
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmMMC1RegisterWrite(t *testing.T) {
+	input := []byte{
+		0xa9, 0x0e, // lda #$0e
+		0x8d, 0x00, 0x80, // sta $8000
+		0x4a,             // lsr a
+		0x8d, 0x00, 0x80, // sta $8000
+		0x4a,             // lsr a
+		0x8d, 0x00, 0x80, // sta $8000
+		0x4a,             // lsr a
+		0x8d, 0x00, 0x80, // sta $8000
+		0x4a,             // lsr a
+		0x8d, 0x00, 0x80, // sta $8000
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda #$0E
+        sta a:Reset                    ; MMC1 register write: control
+        lsr a
+        sta a:Reset
+        lsr a
+        sta a:Reset
+        lsr a
+        sta a:Reset
+        lsr a
+        sta a:Reset
+        rti
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.AnnotateMMC1 = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+		cart.Mapper = 1
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmMMC3IRQWrites(t *testing.T) {
+	input := []byte{
+		0xa9, 0x20, // lda #$20
+		0x8d, 0x00, 0xc0, // sta $C000
+		0x8d, 0x01, 0xc0, // sta $C001
+		0x8d, 0x00, 0xe0, // sta $E000
+		0x8d, 0x01, 0xe0, // sta $E001
+		0x40, // rti
+	}
+
+	expected := map[int]string{
+		0x02: "MMC3 IRQ latch",
+		0x05: "MMC3 IRQ reload",
+		0x08: "MMC3 IRQ disable",
+		0x0b: "MMC3 IRQ enable",
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.OffsetComments = false
+	opts.HexComments = false
+	cart := cartridge.New()
+	cart.Mapper = 4
+	disasm := testProgram(t, opts, cart, input)
+
+	// the written register addresses are inside the PRG, the comments are checked directly
+	// to avoid outputting the data up to the register addresses
+	app, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+	for index, comment := range expected {
+		assert.Equal(t, comment, app.PRG[0].Offsets[index].Comment)
+	}
+}
+
+func TestDisasmNametableUploadLoop(t *testing.T) {
+	input := []byte{
+		0xa9, 0x20, // lda #$20
+		0x8d, 0x06, 0x20, // sta $2006
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x06, 0x20, // sta $2006
+		0xa0, 0x00, // ldy #$00
+		0xb9, 0x20, 0x80, // lda $8020,Y
+		0x8d, 0x07, 0x20, // sta $2007
+		0xc8,       // iny
+		0xc0, 0x04, // cpy #$04
+		0xd0, 0xf5, // bne $800c
+		0x40, // rti
+	}
+
+	expected := `
+        ; PPU registers
+        PPU_ADDR = $2006
+        PPU_DATA = $2007
+        
+        Reset:
+        lda #$20
+        sta PPU_ADDR
+        lda #$00
+        sta PPU_ADDR
+        ldy #$00
+        
+        _label_800c:
+        lda a:nametable_data_8020,Y
+        sta PPU_DATA
+        iny
+        cpy #$04
+        bne _label_800c                ; 4 iterations
+        rti
+        
+        .byte $00, $00, $00, $00, $00, $00, $00, $00
+        
+        nametable_data_8020:
+        .byte $01, $02, $03, $04
+        .byte $05, $06
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+		copy(cart.PRG[0x20:], []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06})
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmSentinelTable(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x10, 0x80, // lda $8010,X
+		0xc9, 0xff, // cmp #$FF
+		0xf0, 0x06, // beq $800f
+		0x9d, 0x00, 0x03, // sta $0300,X
+		0xe8,       // inx
+		0xd0, 0xf3, // bne $8002
+		0x40, // rti
+	}
+
+	expected := `
+_var_0300_indexed = $0300
+
+Reset:
+        ldx #$00
+
+_label_8002:
+        lda a:sentinel_data_8010,X
+        cmp #$FF
+        beq _label_800f
+        sta a:_var_0300_indexed,X
+        inx
+        bne _label_8002
+
+_label_800f:
+        rti
+
+sentinel_data_8010:
+.byte $01, $02, $03, $ff
+.byte $05, $06
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+		copy(cart.PRG[0x10:], []byte{0x01, 0x02, 0x03, 0xff, 0x05, 0x06})
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmUnreachableCode(t *testing.T) {
+	input := []byte{
+		0xa5, 0x00, // lda $00
+		0xf0, 0x04, // beq $8008
+		0xd0, 0x02, // bne $8008
+		0xa2, 0xff, // ldx #$FF
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda z:$00
+        beq _label_8008
+        bne _label_8008
+        ldx #$FF                       ; unreachable code
+
+_label_8008:
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Unreachable = options.UnreachableCode
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmUnreachableDefault(t *testing.T) {
+	input := []byte{
+		0xa5, 0x00, // lda $00
+		0xf0, 0x04, // beq $8008
+		0xd0, 0x02, // bne $8008
+		0xa2, 0xff, // ldx #$FF
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda z:$00
+        beq _label_8008
+        bne _label_8008
+        ldx #$FF
+
+_label_8008:
+        rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmUnreachableData(t *testing.T) {
+	input := []byte{
+		0xa5, 0x00, // lda $00
+		0xf0, 0x04, // beq $8008
+		0xd0, 0x02, // bne $8008
+		0xa2, 0xff, // ldx #$FF
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda z:$00
+        beq _label_8008
+        bne _label_8008
+
+.byte $a2, $ff
+
+_label_8008:
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Unreachable = options.UnreachableData
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmUnreachableDataBranchDestination(t *testing.T) {
+	input := []byte{
+		0xa5, 0x00, // lda $00
+		0xf0, 0x06, // beq $800A
+		0xd0, 0x04, // bne $800A
+		0xae, 0x00, 0x02, // ldx $0200
+		0x40,       // rti
+		0xea,       // nop
+		0x90, 0xf7, // bcc $8004
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda z:$00
+        beq _label_800a
+
+_label_8004:
+        bne _label_800a
+        ldx a:$0200
+        rti
+
+_label_800a:
+        nop
+        bcc _label_8004
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Unreachable = options.UnreachableData
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmEntryLabel(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `Start:
+        jsr _func_8004
+        rti
+
+_func_8004:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.EntryLabel = "Start"
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmXrefComments(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0x20, 0x07, 0x80, // jsr $8007
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr _func_8007
+        jsr _func_8007
+        rti
+
+_func_8007:                      ; called from $8000, $8003
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.XrefComments = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmXrefIndex(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0x20, 0x07, 0x80, // jsr $8007
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr _func_8007
+        jsr _func_8007
+        rti
+
+_func_8007:
+        rts
+
+; === Cross References ===
+; _func_8007 ($8007): called from $8000, $8003
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.XrefIndex = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmTableOfContents(t *testing.T) {
+	input := []byte{
+		0x20, 0x08, 0x80, // jsr $8008
+		0x20, 0x07, 0x80, // jsr $8007
+		0x40, // rti
+		0x60, // rts
+		0x60, // rts
+	}
+
+	expected := `; === Table of Contents ===
+; Reset ($8000)
+; _func_8007 ($8007)
+; _func_8008 ($8008)
+
+Reset:
+        jsr _func_8008
+        jsr _func_8007
+        rti
+
+_func_8007:
+        rts
+
+_func_8008:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.TableOfContents = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmNoAutoLabels(t *testing.T) {
+	input := []byte{
+		0xad, 0x00, 0x02, // lda $0200
+		0x4c, 0x08, 0x80, // jmp $8008
+		0xea,             // nop
+		0xea,             // nop
+		0x20, 0x0c, 0x80, // jsr $800c
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        lda a:$0200
+        jmp $8008
+
+        .byte $ea, $ea
+
+        jsr init
+        rti
+
+init:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.NoAutoLabels = true
+		opts.Renames = map[string]string{"_func_800c": "init"}
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmRenameMap(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xca,       // dex
+		0xd0, 0xfd, // bne $8002
+		0x20, 0x09, 0x80, // jsr $8009
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        ldx #$00
+
+wait_loop:
+        dex
+        bne wait_loop                  ; 256 iterations
+        jsr init_ppu
+        rti
+
+init_ppu:
+        rts
+`
+
+	renames, err := options.ParseRenameMap(strings.NewReader("# renames\n_label_8002=wait_loop\n_func_8009 = init_ppu\n"))
+	assert.NoError(t, err)
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Renames = renames
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmRenameMapVariable(t *testing.T) {
+	input := []byte{
+		0xad, 0x00, 0x02, // lda $0200
+		0x8d, 0x00, 0x02, // sta $0200
+		0x40, // rti
+	}
+
+	expected := `
+ppu_buf = $0200
+
+Reset:
+lda a:ppu_buf
+sta a:ppu_buf
+rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Renames = map[string]string{"_var_0200": "ppu_buf"}
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmExcludeRange(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0x4c, 0x05, 0x80, // jmp $8005
+		0xa2, 0x02, // ldx #$02
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        lda #$01
+        jmp _label_8005
+
+_label_8005:
+.byte $a2, $02, $40
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Exclude = []options.AddressRange{{Start: 0x8005, End: 0x8007}}
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmDecimalMode(t *testing.T) {
+	input := []byte{
+		0xf8,       // sed
+		0x18,       // clc
+		0x69, 0x01, // adc #$01
+		0xd8,       // cld
+		0x69, 0x01, // adc #$01
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        sed                            ; decimal mode (ignored on NES 2A03)
+        clc
+        adc #$01                       ; decimal mode (ignored on NES 2A03)
+        cld                            ; decimal mode (ignored on NES 2A03)
+        adc #$01
+        rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmMultiplication(t *testing.T) {
+	input := []byte{
+		0xa5, 0x10, // lda $10
+		0x85, 0x00, // sta $00
+		0x0a,       // asl a
+		0x0a,       // asl a
+		0x18,       // clc
+		0x65, 0x00, // adc $00
+		0x85, 0x10, // sta $10
+		0x40, // rti
+	}
+
+	expected := `
+_var_0000 = $0000
+_var_0010 = $0010
+
+Reset:
+        lda z:_var_0010
+        sta z:_var_0000
+        asl a
+        asl a
+        clc
+        adc z:_var_0000                ; A * 5
+        sta z:_var_0010
+        rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmNegation(t *testing.T) {
+	input := []byte{
+		0xa5, 0x10, // lda $10
+		0x49, 0xff, // eor #$ff
+		0x18,       // clc
+		0x69, 0x01, // adc #$01
+		0x85, 0x10, // sta $10
+		0x40, // rti
+	}
+
+	expected := `
+_var_0010 = $0010
+
+Reset:
+lda z:_var_0010
+eor #$FF
+clc
+adc #$01                       ; negate A (two's complement)
+sta z:_var_0010
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmSRAM(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0x8d, 0x00, 0x60, // sta $6000
+		0x40, // rti
+	}
+
+	expected := `
+sram_6000 = $6000
+
+Reset:
+        lda #$01
+        sta a:sram_6000                ; battery-backed save RAM
+        rti
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+		cart.Battery = 1
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmOAMBuffer(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xa9, 0x10, // lda #$10
+		0x9d, 0x00, 0x02, // sta $0200,X
+		0x8d, 0x03, 0x02, // sta $0203
+		0xa9, 0x02, // lda #$02
+		0x8d, 0x14, 0x40, // sta $4014
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+OAM_DMA = $4014
+
+
+oam_buffer = $0200
+
+Reset:
+        ldx #$00
+        lda #$10
+        sta a:oam_buffer,X
+        sta a:oam_buffer+3
+        lda #$02
+        sta OAM_DMA                    ; OAM DMA without OAMADDR reset
+        rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmOAMAddressReset(t *testing.T) {
+	input := []byte{
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x03, 0x20, // sta $2003
+		0xa9, 0x02, // lda #$02
+		0x8d, 0x14, 0x40, // sta $4014
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+OAM_ADDR = $2003
+OAM_DMA = $4014
+
+Reset:
+lda #$00
+sta OAM_ADDR                   ; reset OAMADDR before DMA
+lda #$02
+sta OAM_DMA
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmOAMAddressResetMissing(t *testing.T) {
+	input := []byte{
+		0xa9, 0x04, // lda #$04
+		0x8d, 0x03, 0x20, // sta $2003
+		0xa9, 0x02, // lda #$02
+		0x8d, 0x14, 0x40, // sta $4014
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+OAM_ADDR = $2003
+OAM_DMA = $4014
+
+Reset:
+lda #$04
+sta OAM_ADDR
+lda #$02
+sta OAM_DMA                    ; OAM DMA without OAMADDR reset
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmAnnotateAddressing(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0xbd, 0x00, 0x02, // lda $0200,X
+		0x85, 0x04, // sta $04
+		0x0a, // asl a
+		0x40, // rti
+	}
+
+	expected := `
+_var_0200_indexed = $0200
+
+Reset:
+        lda #$01                       ; immediate
+        lda a:_var_0200_indexed,X      ; absolute,X
+        sta z:$04                      ; zeropage
+        asl a                          ; accumulator
+        rti                            ; implied
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.AnnotateAddressing = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmBranchOffsetComments(t *testing.T) {
+	input := []byte{
+		0xca,       // dex
+		0xd0, 0x02, // bne $8005
+		0xd0, 0xfb, // bne $8000
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        dex
+        bne _label_8005                ; +2
+        bne Reset                      ; -5
+
+_label_8005:
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.BranchOffsetComments = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmAnnotateIndexed(t *testing.T) {
+	input := []byte{
+		0xbd, 0x00, 0x03, // lda $0300,X
+		0xb9, 0x00, 0x04, // lda $0400,Y
+		0x40, // rti
+	}
+
+	expected := `
+_var_0300_indexed = $0300
+_var_0400_indexed = $0400
+
+Reset:
+        lda a:_var_0300_indexed,X      ; base=_var_0300_indexed, +X
+        lda a:_var_0400_indexed,Y      ; base=_var_0400_indexed, +Y
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.AnnotateIndexed = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmIdenticalFunctions(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0x20, 0x0c, 0x80, // jsr $800c
+		0x40,       // rti
+		0xa9, 0x01, // lda #$01
+		0x85, 0x10, // sta $10
+		0x60,       // rts
+		0xa9, 0x01, // lda #$01
+		0x85, 0x10, // sta $10
+		0x60, // rts
+	}
+
+	expected := `
+_var_0010 = $0010
+
+Reset:
+        jsr _func_8007
+        jsr _func_800c
+        rti
+
+_func_8007:
+        lda #$01
+        sta z:_var_0010
+        rts
+
+_func_800c:                      ; identical to _func_8007
+        lda #$01
+        sta z:_var_0010
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.CollapseIdenticalFunctions = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmKnownFunctions(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa9, 0x01, // lda #$01
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr init_ppu
+        rti
+
+init_ppu:                        ; recognized: init_ppu from SomeLib
+        lda #$01
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.KnownFunctions = map[uint32]options.KnownFunction{
+			crc32.ChecksumIEEE([]byte{0xa9, 0x01, 0x60}): {Name: "init_ppu", Library: "SomeLib"},
+		}
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmKnownFunctionsIdentical(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0x20, 0x0a, 0x80, // jsr $800a
+		0x40,       // rti
+		0xa9, 0x01, // lda #$01
+		0x60,       // rts
+		0xa9, 0x01, // lda #$01
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr init_ppu
+        jsr _func_800a
+        rti
+
+init_ppu:                        ; recognized: init_ppu from SomeLib
+        lda #$01
+        rts
+
+_func_800a:                      ; recognized: init_ppu from SomeLib  identical to init_ppu
+        lda #$01
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.KnownFunctions = map[uint32]options.KnownFunction{
+			crc32.ChecksumIEEE([]byte{0xa9, 0x01, 0x60}): {Name: "init_ppu", Library: "SomeLib"},
+		}
+		opts.CollapseIdenticalFunctions = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmKnownFunctionsNameUsed(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa9, 0x01, // lda #$01
+		0x60, // rts
+	}
+
+	expected := `init_ppu:
+        jsr _func_8004
+        rti
+
+_func_8004:                      ; recognized: init_ppu
+        lda #$01
+        rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.KnownFunctions = map[uint32]options.KnownFunction{
+			crc32.ChecksumIEEE([]byte{0xa9, 0x01, 0x60}): {Name: "init_ppu"},
+		}
+		opts.Renames = map[string]string{"Reset": "init_ppu"}
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmLineEndingsCRLF(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0x40, // rti
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.CodeOnly = true
+	opts.OffsetComments = false
+	opts.HexComments = false
+	opts.LineEndings = options.LineEndingsCRLF
+	disasm := testProgram(t, opts, cartridge.New(), input)
+
+	var buffer bytes.Buffer
+	_, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+
+	expected := "Reset:\r\n  lda #$01\r\n  rti\r\n"
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestDisasmAddressPrefix(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0x4c, 0x06, 0x80, // jmp $8006
+		0xff, // data
+		0x40, // rti
+	}
+
+	expected := `Reset:
+@8000   lda #$01
+@8002   jmp _label_8006
+
+@8005 .byte $ff
+
+_label_8006:
+@8006   rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.AddressPrefix = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmCoverage(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0x4c, 0x08, 0x80, // jmp $8008
+		0xff, 0xff, 0xff, // data
+		0x40, // rti
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.Exclude = []options.AddressRange{{Start: 0x8005, End: 0x8006}}
+	cart := cartridge.New()
+	disasm := testProgram(t, opts, cart, input)
+
+	_, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+
+	coverage := disasm.Coverage()
+	assert.Equal(t, 6, coverage.Code)
+	assert.Equal(t, 2, coverage.Excluded)
+	assert.Equal(t, 0x8000-8, coverage.Data)
+}
+
+func TestDisasmOpcodeHistogram(t *testing.T) {
+	input := []byte{
+		0xa9, 0x01, // lda #$01
+		0xa9, 0x02, // lda #$02
+		0xea,       // nop
+		0xa9, 0x03, // lda #$03
+		0x40, // rti
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	cart := cartridge.New()
+	disasm := testProgram(t, opts, cart, input)
+
+	_, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+
+	expected := []mapper.OpcodeCount{
+		{Opcode: 0xa9, Instruction: "lda", Count: 3},
+		{Opcode: 0x40, Instruction: "rti", Count: 1},
+		{Opcode: 0xea, Instruction: "nop", Count: 1},
+	}
+	assert.Equal(t, expected, disasm.OpcodeHistogram())
+}
+
+func TestDisasmSplitHeader(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.SplitHeader = true
+	cart := cartridge.New()
+	disasm := testProgram(t, opts, cart, []byte{0x40}) // rti
+
+	var mainBuffer, headerBuffer bytes.Buffer
+	var headerFileName string
+	newBankWriter := func(baseName string) (io.WriteCloser, error) {
+		headerFileName = baseName
+		return nopWriteCloser{Writer: &headerBuffer}, nil
+	}
+
+	_, err := disasm.Process(context.Background(), &mainBuffer, newBankWriter)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "header.inc", headerFileName)
+	assert.True(t, strings.Contains(mainBuffer.String(), `.include "header.inc"`))
+	assert.False(t, strings.Contains(mainBuffer.String(), `.segment "HEADER"`))
+	assert.True(t, strings.Contains(headerBuffer.String(), `.segment "HEADER"`))
+	assert.True(t, strings.Contains(headerBuffer.String(), "Number of 16KB PRG-ROM banks"))
+}
+
+func TestDisasmCHRFile(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.CHRFile = "tiles.chr"
+	cart := cartridge.New()
+	cart.CHR = make([]byte, 0x2000)
+	cart.CHR[0x10] = 0x7e
+	disasm := testProgram(t, opts, cart, []byte{0x40}) // rti
+
+	var mainBuffer, chrBuffer bytes.Buffer
+	var chrFileName string
+	newBankWriter := func(baseName string) (io.WriteCloser, error) {
+		chrFileName = baseName
+		return nopWriteCloser{Writer: &chrBuffer}, nil
+	}
+
+	_, err := disasm.Process(context.Background(), &mainBuffer, newBankWriter)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "tiles.chr", chrFileName)
+	assert.Equal(t, cart.CHR, chrBuffer.Bytes())
+	assert.True(t, strings.Contains(mainBuffer.String(), `.incbin "tiles.chr"`))
+	assert.False(t, strings.Contains(mainBuffer.String(), "$7E"))
+}
+
+func TestDisasmQualityForcedBytes(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.Exclude = []options.AddressRange{{Start: 0x9000, End: 0x9FFF}}
+	disasm := testProgram(t, opts, cartridge.New(), []byte{0x40}) // rti
+
+	_, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+
+	quality := disasm.Quality()
+	assert.Equal(t, 12.5, quality.ForcedPercent)
+	assert.NoError(t, quality.Check(options.QualityThresholds{MaxForcedPercent: 15}))
+
+	err = quality.Check(options.QualityThresholds{MaxForcedPercent: 10})
+	var qualityErr *QualityError
+	assert.True(t, errors.As(err, &qualityErr))
+	assert.Equal(t, ExitCodeForcedBytes, qualityErr.ExitCode)
+}
+
+func TestDisasmQualityCodeAsData(t *testing.T) {
+	input := []byte{
+		0x04, 0x00, // unofficial nop $00
+		0x40, // rti
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.NoUnofficialInstructions = true
+	disasm := testProgram(t, opts, cartridge.New(), input)
+
+	_, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, disasm.Coverage().CodeAsData)
+	assert.Equal(t, float64(2)*100/0x8000, disasm.Quality().ForcedPercent)
+}
+
+func TestDisasmExports(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `.export Reset
+.export _func_8004
+
+Reset:
+jsr _func_8004
+rti
+
+_func_8004:
+rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.Exports = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmStackCheck(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0x20, 0x0a, 0x80, // jsr $800a
+		0x40, // rti
+		0x48, // pha
+		0x68, // pla
+		0x60, // rts
+		0x48, // pha
+		0x60, // rts
+	}
+
+	expected := `Reset:
+jsr _func_8007
+jsr _func_800a
+rti
+
+_func_8007:
+pha
+pla
+rts
+
+_func_800a:
+pha
+rts                            ; stack imbalance suspected
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.StackCheck = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmMaxDataRun(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.CodeOnly = true
+	opts.MaxDataRun = 1024
+	cart := cartridge.New()
+
+	input := append([]byte{0x40}, bytes.Repeat([]byte{0xff}, 4096)...) // rti, 4KB data
+	disasm := testProgram(t, opts, cart, input)
+
+	var buffer bytes.Buffer
+	_, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+
+	output := buffer.String()
+	for block := range 4 {
+		assert.True(t, strings.Contains(output, fmt.Sprintf("data_8001_%d:", block)))
+	}
+	assert.False(t, strings.Contains(output, "data_8001_4:"))
+	assert.Equal(t, 4096/16, strings.Count(output, ".byte"))
+}
+
+func TestDisasmMaxDataRunLabeled(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.CodeOnly = true
+	opts.MaxDataRun = 1024
+	cart := cartridge.New()
+
+	input := []byte{
+		0xad, 0x04, 0x80, // lda $8004
+		0x40, // rti
+	}
+	input = append(input, bytes.Repeat([]byte{0xff}, 2048)...) // 2KB data
+	disasm := testProgram(t, opts, cart, input)
+
+	var buffer bytes.Buffer
+	_, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+
+	// the first block keeps the label of the run
+	output := buffer.String()
+	assert.True(t, strings.Contains(output, "_data_8004:\n.byte"))
+	assert.False(t, strings.Contains(output, "data_8004_0:"))
+	assert.True(t, strings.Contains(output, "data_8004_1:"))
+}
+
+func TestDisasmCHRSummary(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.CHRSummary = true
+	cart := cartridge.New()
+	cart.CHR = make([]byte, 4*16)
+	cart.CHR[1*16] = 0x01
+	cart.CHR[3*16+15] = 0x80
+	disasm := testProgram(t, opts, cart, []byte{0x40}) // rti
+
+	var buffer bytes.Buffer
+	_, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+
+	output := buffer.String()
+	assert.True(t, strings.Contains(output, "; CHR summary: 4 tiles, 2 non-blank, 2 blank"))
+	assert.True(t, strings.Contains(output, "; $000: .#.#\n"))
+}
+
+func TestDisasmConstantGroups(t *testing.T) {
+	input := []byte{
+		0xad, 0x16, 0x40, // lda $4016
+		0x8d, 0x15, 0x40, // sta $4015
+		0x8d, 0x00, 0x20, // sta $2000
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_CTRL = $2000
+
+; APU registers
+APU_SND_CHN = $4015
+
+; Controller
+JOYPAD1 = $4016
+
+Reset:
+lda JOYPAD1
+sta APU_SND_CHN
+sta PPU_CTRL
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmCancelledContext(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	cart := cartridge.New()
+	disasm := testProgram(t, opts, cart, []byte{0x40}) // rti
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := disasm.Disassemble(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDisasmPPULatchReset(t *testing.T) {
+	input := []byte{
+		0x2c, 0x02, 0x20, // bit $2002
+		0xad, 0x02, 0x20, // lda $2002
+		0x10, 0xfb, // bpl $8003
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_STATUS = $2002
+
+Reset:
+bit PPU_STATUS                 ; reset PPU $2006 latch
+
+_label_8003:
+lda PPU_STATUS
+bpl _label_8003
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmPPUBufferedRead(t *testing.T) {
+	input := []byte{
+		0xad, 0x07, 0x20, // lda $2007
+		0xad, 0x07, 0x20, // lda $2007
+		0x85, 0x10, // sta $10
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_DATA = $2007
+
+Reset:
+lda PPU_DATA                   ; PPU buffered read (dummy + real)
+lda PPU_DATA
+sta z:$10
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmPPUUnrolledUpload(t *testing.T) {
+	input := []byte{
+		0xa9, 0x0f, // lda #$0f
+		0x8d, 0x07, 0x20, // sta $2007
+		0xa9, 0x16, // lda #$16
+		0x8d, 0x07, 0x20, // sta $2007
+		0xa2, 0x27, // ldx #$27
+		0x8e, 0x07, 0x20, // stx $2007
+		0x8e, 0x07, 0x20, // stx $2007
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_DATA = $2007
+
+Reset:
+lda #$0F                       ; unrolled PPU upload, 4 bytes
+sta PPU_DATA
+lda #$16
+sta PPU_DATA
+ldx #$27
+stx PPU_DATA
+stx PPU_DATA
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmAliasDecimal(t *testing.T) {
+	input := []byte{
+		0xad, 0x00, 0x02, // lda $0200
+		0x8d, 0x07, 0x20, // sta $2007
+		0xad, 0x00, 0x02, // lda $0200
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_DATA = $2007               ; 8199
+
+
+_var_0200 = $0200              ; 512
+
+Reset:
+lda a:_var_0200
+sta PPU_DATA
+lda a:_var_0200
+rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.AliasDecimal = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmSymbolicOffsets(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x0b, 0x80, // lda $800b,X
+		0xad, 0x0f, 0x80, // lda $800f
+		0x85, 0x10, // sta $10
+		0x40,                   // rti
+		0x04, 0x01, 0x02, 0x03, // table of offsets
+		0x55, // target
+	}
+
+	expected := `Reset:
+ldx #$00
+lda a:_data_800b_indexed,X
+lda a:_data_800f
+sta z:$10
+rti
+
+_data_800b_indexed:
+.byte _data_800f-_data_800b_indexed, $01, $02, $03
+
+_data_800f:
+.byte $55
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.SymbolicOffsets = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmMaxLineLengthSymbolicOffsets(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x0b, 0x80, // lda $800b,X
+		0xad, 0x0f, 0x80, // lda $800f
+		0x85, 0x10, // sta $10
+		0x40,                   // rti
+		0x04, 0x01, 0x02, 0x03, // table of offsets
+		0x55, // target
+	}
+
+	expected := `Reset:
+ldx #$00
+lda a:_data_800b_indexed,X
+lda a:_data_800f
+sta z:$10
+rti
+
+_data_800b_indexed:
+.byte _data_800f-_data_800b_indexed, $01
+.byte $02, $03
+
+_data_800f:
+.byte $55
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.SymbolicOffsets = true
+		opts.MaxLineLength = 40
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmMaxDataRunSymbolicOffsets(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x0b, 0x80, // lda $800b,X
+		0xad, 0x0f, 0x80, // lda $800f
+		0x85, 0x10, // sta $10
+		0x40,                   // rti
+		0x04, 0x01, 0x02, 0x03, // table of offsets
+		0x55, // target
+	}
+
+	expected := `Reset:
+ldx #$00
+lda a:_data_800b_indexed,X
+lda a:_data_800f
+sta z:$10
+rti
+
+_data_800b_indexed:
+.byte _data_800f-_data_800b_indexed, $01
+
+data_800b_1:
+.byte _data_800f-data_800b_1, $03
+
+_data_800f:
+.byte $55
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.SymbolicOffsets = true
+		opts.MaxDataRun = 2
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmBankScopes(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.CodeOnly = true
+	opts.OffsetComments = false
+	opts.BankScopes = true
+
+	app := program.New(cartridge.New())
+	bank0 := program.NewPRGBank(16)
+	bank0.Offsets[0] = program.Offset{
+		Type:  program.CodeOffset,
+		Data:  []byte{0x4c, 0x00, 0x80},
+		Label: "Reset",
+		Code:  "jmp common",
+	}
+	bank1 := program.NewPRGBank(16)
+	bank1.Offsets[0] = program.Offset{
+		Type:  program.CodeOffset,
+		Data:  []byte{0x60},
+		Label: "common",
+		Code:  "rts",
+	}
+	app.PRG = []*program.PRGBank{bank0, bank1}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, ca65.New(app, opts, &buffer, nil).Write())
+
+	expected := `
+.scope bank0
+Reset:
+jmp bank1::common
+.endscope
+
+.scope bank1
+common:
+rts
+.endscope
+`
+	assert.Equal(t, trimStringList(expected), trimStringList(buffer.String()))
+}
+
+func TestDisasmBankScopesVectors(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.OffsetComments = false
+	opts.BankScopes = true
+	opts.Exports = true
+
+	app := program.New(cartridge.New())
+	app.Handlers = program.Handlers{NMI: "0", Reset: "Reset", IRQ: "0"}
+	bank0 := program.NewPRGBank(16)
+	bank0.Offsets[0] = program.Offset{
+		Type:  program.CodeOffset | program.CallDestination,
+		Data:  []byte{0x40},
+		Label: "Reset",
+		Code:  "rti",
+	}
+	app.PRG = []*program.PRGBank{bank0}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, ca65.New(app, opts, &buffer, nil).Write())
+
+	output := buffer.String()
+	assert.True(t, strings.Contains(output, ".export Reset := bank0::Reset\n"))
+	assert.True(t, strings.Contains(output, ".addr 0, bank0::Reset, 0\n"))
+}
+
+func TestDisasmChecksumLoop(t *testing.T) {
+	input := []byte{
+		0xa9, 0x00, // lda #$00
+		0xa8,             // tay
+		0x59, 0x00, 0x80, // eor $8000,Y
+		0xc8,       // iny
+		0xd0, 0xfa, // bne $8003
+		0x40, // rti
+	}
+
+	expected := `Reset:
+lda #$00
+tay
+
+_label_8003:
+eor a:Reset,Y                  ; ROM checksum loop
+iny
+bne _label_8003
+rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.AnnotateChecksum = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmHexdumpData(t *testing.T) {
+	input := []byte{
+		0x40,             // rti
+		0x48, 0x69, 0x21, // "Hi!"
+		0x00, 0xff,
+	}
+
+	expected := `Reset:
+rti
+
+.byte $48, $69, $21, $00, $ff                                                        ; |Hi!..|
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.HexdumpData = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmPaletteUpload(t *testing.T) {
+	input := []byte{
+		0xa9, 0x3f, // lda #$3f
+		0x8d, 0x06, 0x20, // sta $2006
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x06, 0x20, // sta $2006
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x20, 0x80, // lda $8020,X
+		0x8d, 0x07, 0x20, // sta $2007
+		0xe8,       // inx
+		0xe0, 0x08, // cpx #$08
+		0xd0, 0xf5, // bne $800c
+		0x40, // rti
+	}
+	input = append(input, make([]byte, 8)...)
+	input = append(input, 0x0f, 0x16, 0x27, 0x30, 0x0f, 0x01, 0x11, 0x21)
+	input = append(input, bytes.Repeat([]byte{0x01}, 24)...)
+
+	expected := `
+; PPU registers
+PPU_ADDR = $2006
+PPU_DATA = $2007
+
+Reset:
+lda #$3F
+sta PPU_ADDR
+lda #$00
+sta PPU_ADDR
+ldx #$00
+
+_label_800c:
+lda a:palette_8020,X
+sta PPU_DATA
+inx
+cpx #$08
+bne _label_800c                ; 8 iterations
+rti
+
+.byte $00, $00, $00, $00, $00, $00, $00, $00
+
+palette_8020:
+.byte $0f                        ; background palette 0 color 0
+.byte $16                        ; background palette 0 color 1
+.byte $27                        ; background palette 0 color 2
+.byte $30                        ; background palette 0 color 3
+.byte $0f                        ; background palette 1 color 0
+.byte $01                        ; background palette 1 color 1
+.byte $11                        ; background palette 1 color 2
+.byte $21                        ; background palette 1 color 3
+.byte $01, $01, $01, $01, $01, $01, $01, $01, $01, $01, $01, $01, $01, $01, $01, $01
+.byte $01, $01, $01, $01, $01, $01, $01, $01
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmAttributeTableUpload(t *testing.T) {
+	input := []byte{
+		0xa9, 0x23, // lda #$23
+		0x8d, 0x06, 0x20, // sta $2006
+		0xa9, 0xc0, // lda #$c0
+		0x8d, 0x06, 0x20, // sta $2006
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x20, 0x80, // lda $8020,X
+		0x8d, 0x07, 0x20, // sta $2007
+		0xe8,       // inx
+		0xe0, 0x08, // cpx #$08
+		0xd0, 0xf5, // bne $800c
+		0x40, // rti
+	}
+	input = append(input, make([]byte, 8)...)
+	input = append(input, bytes.Repeat([]byte{0x55}, 8)...)
+	input = append(input, bytes.Repeat([]byte{0x01}, 8)...)
+
+	expected := `
+; PPU registers
+PPU_ADDR = $2006
+PPU_DATA = $2007
+
+Reset:
+lda #$23
+sta PPU_ADDR
+lda #$C0
+sta PPU_ADDR
+ldx #$00
+
+_label_800c:
+lda a:attributes_8020,X
+sta PPU_DATA
+inx
+cpx #$08
+bne _label_800c                ; 8 iterations
+rti
+
+.byte $00, $00, $00, $00, $00, $00, $00, $00
+
+attributes_8020:
+.byte $55, $55, $55, $55, $55, $55, $55, $55
+.byte $01, $01, $01, $01, $01, $01, $01, $01
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmDeterministicOutput(t *testing.T) {
+	input := []byte{
+		0x20, 0x0c, 0x80, // jsr $800c
+		0xad, 0x16, 0x40, // lda $4016
+		0x8d, 0x15, 0x40, // sta $4015
+		0x8d, 0x00, 0x20, // sta $2000
+		0xa5, 0x10, // 800c: lda $10
+		0x85, 0x11, // sta $11
+		0xe6, 0x10, // inc $10
+		0xa5, 0x11, // lda $11
+		0xd0, 0xf6, // bne $800c
+		0x60, // rts
+	}
+
+	disassemble := func() string {
+		opts := options.NewDisassembler(assembler.Ca65)
+		opts.XrefComments = true
+		disasm := testProgram(t, opts, cartridge.New(), input)
+
+		var buffer bytes.Buffer
+		_, err := disasm.Process(context.Background(), &buffer, nil)
+		assert.NoError(t, err)
+		return buffer.String()
+	}
+
+	expected := disassemble()
+	for range 10 {
+		assert.Equal(t, expected, disassemble())
+	}
+}
+
+func TestDisasmRTSTrampoline(t *testing.T) {
+	input := []byte{
+		0x20, 0x06, 0x80, // jsr $8006
+		0xa9, 0x01, // inline data that would decode as lda #$01
+		0x40, // inline data that would decode as rti
+		0x68, // pla
+		0xa8, // tay
+		0x68, // pla
+		0x48, // pha
+		0x98, // tya
+		0x48, // pha
+		0x60, // rts
+	}
+
+	expected := `Reset:
+jsr _func_8006                 ; calls RTS trampoline, following bytes may be data
+
+.byte $a9, $01, $40
+
+_func_8006:                      ; RTS trampoline / return-address dispatch
+pla
+tay
+pla
+pha
+tya
+pha
+rts
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmJumpTable(t *testing.T) {
+	input := []byte{
+		0xa5, 0x10, // lda z:$10
+		0xf0, 0x01, // beq $8005
+		0x40,             // rti
+		0x4c, 0x0e, 0x80, // jmp $800E
+		0x4c, 0x10, 0x80, // jmp $8010
+		0x4c, 0x12, 0x80, // jmp $8012
+		0xe8, // inx
+		0x40, // rti
+		0xc8, // iny
+		0x40, // rti
+		0xca, // dex
+		0x40, // rti
+	}
+
+	expected := `Reset:
+lda z:$10
+beq _label_8005
+rti
+
+_label_8005:
+jmp _label_800e                ; jump/call table
+jmp _label_8010
+jmp _label_8012
+
+_label_800e:
+inx
+rti
+
+_label_8010:
+iny
+rti
+
+_label_8012:
+dex
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmSeedFromCDLCalls(t *testing.T) {
+	input := []byte{
+		0x40, // rti
+		0xe8, // inx
+		0x40, // rti
+	}
+
+	expected := `Reset:
+rti
+
+_func_8001:
+inx
+rti
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+		opts.SeedFromCDLCalls = true
+
+		prgFlags := make([]byte, len(cart.PRG))
+		prgFlags[1] = byte(codedatalog.SubEntryPoint)
+		opts.CodeDataLog = io.NopCloser(bytes.NewReader(prgFlags))
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmCanonicalOperands(t *testing.T) {
+	input := []byte{
+		0xa9, 0x00, // lda #<Reset
+		0x85, 0x10, // sta z:$10
+		0xa9, 0x80, // lda #>Reset
+		0x85, 0x11, // sta z:$11
+		0xad, 0x00, 0x02, // lda a:$0200
+		0x6c, 0x00, 0x02, // jmp ($0200)
+	}
+
+	disassemble := func(assemblerName string, syntax assembler.Syntax,
+		fileWriterConstructor FileWriterConstructor, canonical bool) string {
+
+		opts := options.NewDisassembler(assemblerName)
+		opts.CanonicalOperands = canonical
+		opts.OffsetComments = false
+		opts.HexComments = false
+		cart := cartridge.New()
+		cart.PRG[0x7FFD] = 0x80
+		copy(cart.PRG, input)
+
+		ar := m6502.New(syntax)
+		disasm, err := New(ar, log.NewTestLogger(t), cart, opts, fileWriterConstructor)
+		assert.NoError(t, err)
+
+		var buffer bytes.Buffer
+		_, err = disasm.Process(context.Background(), &buffer, nil)
+		assert.NoError(t, err)
+		return buffer.String()
+	}
+
+	output := disassemble(assembler.Ca65, ca65.Syntax, ca65.New, false)
+	assert.True(t, strings.Contains(output, "lda #<Reset\n"))
+	assert.True(t, strings.Contains(output, "sta z:$10\n"))
+	assert.True(t, strings.Contains(output, "lda a:_var_0200\n"))
+	assert.False(t, strings.Contains(output, "canonical syntax"))
+
+	output = disassemble(assembler.Nesasm, nesasm.Syntax, nesasm.New, false)
+	assert.True(t, strings.Contains(output, "lda #LOW(Reset)\n"))
+	assert.True(t, strings.Contains(output, "lda #HIGH(Reset)\n"))
+	assert.True(t, strings.Contains(output, "sta <$10\n"))
+	assert.True(t, strings.Contains(output, "jmp [_var_0200]\n"))
+
+	output = disassemble(assembler.Ca65, assembler.CanonicalSyntax, ca65.New, true)
+	assert.True(t, strings.Contains(output, "lda #<Reset\n"))
+	assert.True(t, strings.Contains(output, "lda #>Reset\n"))
+	assert.True(t, strings.Contains(output, "sta $10\n"))
+	assert.True(t, strings.Contains(output, "lda _var_0200\n"))
+	assert.True(t, strings.Contains(output, "jmp (_var_0200)\n"))
+	assert.True(t, strings.Contains(output, "; "+assembler.CanonicalOperandsDescription+"\n"))
+}
+
+func TestDisasmMaxLineLength(t *testing.T) {
+	input := append([]byte{0x40}, bytes.Repeat([]byte{0xff}, 20)...) // rti, data
+
+	tests := []struct {
+		name      string
+		setup     func(opts *options.Disassembler)
+		dataLines int
+	}{
+		{"default options", func(_ *options.Disassembler) {}, 3},
+		{"without comments", func(opts *options.Disassembler) {
+			opts.OffsetComments = false
+			opts.HexComments = false
+		}, 3},
+		{"hexdump", func(opts *options.Disassembler) {
+			opts.HexdumpData = true
+		}, 4},
+	}
+
+	for _, test := range tests {
+		opts := options.NewDisassembler(assembler.Ca65)
+		opts.CodeOnly = true
+		opts.MaxLineLength = 50
+		test.setup(&opts)
+		disasm := testProgram(t, opts, cartridge.New(), input)
+
+		var buffer bytes.Buffer
+		_, err := disasm.Process(context.Background(), &buffer, nil)
+		assert.NoError(t, err)
+
+		var dataLines int
+		for _, line := range strings.Split(buffer.String(), "\n") {
+			if !strings.HasPrefix(line, ".byte") {
+				continue
+			}
+			dataLines++
+			assert.True(t, len(line) <= 50, test.name+": line exceeds maximum length: "+line)
+		}
+		assert.Equal(t, test.dataLines, dataLines, test.name)
+	}
+}
+
+func TestDisasmPPUScrollWrites(t *testing.T) {
+	input := []byte{
+		0xa9, 0x10, // lda #$10
+		0x8d, 0x05, 0x20, // sta $2005
+		0x8e, 0x05, 0x20, // stx $2005
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_SCROLL = $2005
+
+Reset:
+lda #$10
+sta PPU_SCROLL                 ; set PPU scroll X to 16
+stx PPU_SCROLL                 ; set PPU scroll Y
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmPPUControlWrites(t *testing.T) {
+	input := []byte{
+		0xa9, 0x90, // lda #$90
+		0x8d, 0x00, 0x20, // sta $2000
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_CTRL = $2000
+
+Reset:
+lda #$90
+sta PPU_CTRL                   ; PPUCTRL: NMI on, 8x8 sprites, sprites $0000, BG $1000, nametable $2000, VRAM +1
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmPPUMaskWrites(t *testing.T) {
+	input := []byte{
+		0xa9, 0x1e, // lda #$1e
+		0x8d, 0x01, 0x20, // sta $2001
+		0xa9, 0x18, // lda #$18
+		0x8d, 0x01, 0x20, // sta $2001
+		0x40, // rti
+	}
+
+	expected := `
+; PPU registers
+PPU_MASK = $2001
+
+Reset:
+lda #$1E
+sta PPU_MASK                   ; PPUMASK: BG on, sprites on
+lda #$18
+sta PPU_MASK                   ; PPUMASK: BG on, BG left clipped, sprites on, sprites left clipped
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmElementTableAccess(t *testing.T) {
+	input := []byte{
+		0xa5, 0x10, // lda $10
+		0x0a,             // asl a
+		0x0a,             // asl a
+		0xaa,             // tax
+		0xbd, 0x0b, 0x80, // lda $800b,X
+		0x85, 0x11, // sta $11
+		0x40,                   // rti
+		0x01, 0x02, 0x03, 0x04, // table
+	}
+
+	expected := `Reset:
+lda z:$10
+asl a
+asl a
+tax
+lda a:_data_800b_indexed,X     ; table of 4-byte elements
+sta z:$11
+rti
+
+_data_800b_indexed:
+.byte $01, $02, $03, $04
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmWordIncrement(t *testing.T) {
+	input := []byte{
+		0xe6, 0x10, // inc $10
+		0xd0, 0x02, // bne $8006
+		0xe6, 0x11, // inc $11
+		0x40, // rti
+	}
+
+	expected := `
+_word_0010 = $0010
+
+Reset:
+inc z:_word_0010               ; increment 16-bit value at $10
+bne _label_8006
+inc z:_word_0010+1
+
+_label_8006:
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmWordIncrementRAMEnd(t *testing.T) {
+	input := []byte{
+		0xee, 0xfe, 0x1f, // inc $1FFE
+		0xd0, 0x03, // bne $8008
+		0xee, 0xff, 0x1f, // inc $1FFF
+		0x40, // rti
+	}
+
+	expected := `
+_word_1ffe = $1FFE
+
+Reset:
+inc a:_word_1ffe               ; increment 16-bit value at $1FFE
+bne _label_8008
+inc a:_word_1ffe+1
+
+_label_8008:
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmFarCall(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa9, 0x03, // lda #$03
+		0x8d, 0x00, 0x80, // sta $8000
+		0x20, 0x12, 0x80, // jsr $8012
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x00, 0x80, // sta $8000
+		0x60, // rts
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr _func_8004
+        rti
+
+_func_8004:                      ; far-call trampoline to bank 3
+        lda #$03
+        sta a:Reset
+        jsr _func_8012
+        lda #$00
+        sta a:Reset
+        rts
+
+_func_8012:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		cart.Mapper = 2
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmFarCallCalleeBank(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa9, 0x02, // lda #$02
+		0x8d, 0x00, 0x80, // sta $8000
+		0x20, 0x12, 0x80, // jsr $8012
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x00, 0x80, // sta $8000
+		0x60, // rts
+		0x60, // rts
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.OffsetComments = false
+	opts.HexComments = false
+	cart := cartridge.New()
+	cart.Mapper = 2
+	cart.PRG = make([]byte, 0x10000)
+	cart.PRG[0xfffd] = 0x80
+	copy(cart.PRG[0x8012:], []byte{0xa9, 0x01, 0x60}) // callee in bank 2: lda #$01, rts
+	disasm := testProgram(t, opts, cart, input)
+
+	// the callee is resolved into the 16KB bank 2 that is contained in the second 32KB PRG bank,
+	// the labels are checked directly to avoid outputting the data of both banks
+	app, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "far-call trampoline to bank 2", app.PRG[0].Offsets[0x04].LabelComment)
+	assert.Equal(t, "_func_8012", app.PRG[0].Offsets[0x12].Label)
+	assert.Equal(t, "_bank_2_func_8012", app.PRG[1].Offsets[0x12].Label)
+	assert.Equal(t, "far-call destination in bank 2", app.PRG[1].Offsets[0x12].LabelComment)
+}
+
+func TestDisasmFarCallMMC3(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa9, 0x06, // lda #$06
+		0x8d, 0x00, 0x80, // sta $8000
+		0xa9, 0x05, // lda #$05
+		0x8d, 0x01, 0x80, // sta $8001
+		0x20, 0x1c, 0x80, // jsr $801c
+		0xa9, 0x06, // lda #$06
+		0x8d, 0x00, 0x80, // sta $8000
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x01, 0x80, // sta $8001
+		0x60, // rts
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr _func_8004
+        rti
+
+_func_8004:                      ; far-call trampoline to bank 5
+        lda #$06
+        sta a:Reset
+        lda #$05
+        sta a:Reset+1
+        jsr _func_801c
+        lda #$06
+        sta a:Reset
+        lda #$00
+        sta a:Reset+1
+        rts
+
+_func_801c:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		cart.Mapper = 4
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmFarCallMMC1(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa9, 0x03, // lda #$03
+		0x8d, 0x00, 0x80, // sta $8000
+		0x20, 0x12, 0x80, // jsr $8012
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x00, 0x80, // sta $8000
+		0x60, // rts
+		0x60, // rts
+	}
+
+	expected := `Reset:
+        jsr _func_8004
+        rti
+
+_func_8004:
+        lda #$03
+        sta a:Reset
+        jsr _func_8012
+        lda #$00
+        sta a:Reset
+        rts
+
+_func_8012:
+        rts
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		cart.Mapper = 1
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmBankTableSwitch(t *testing.T) {
+	input := []byte{
+		0xa2, 0x01, // ldx #$01
+		0xbd, 0x09, 0x80, // lda $8009,X
+		0x8d, 0x00, 0x80, // sta $8000
+		0x40,                   // rti
+		0x00, 0x01, 0x02, 0x03, // bank numbers
+	}
+
+	expected := `Reset:
+ldx #$01
+lda a:bank_table_8009,X
+sta a:Reset                    ; bank switch via lookup table
+rti
+
+bank_table_8009:
+.byte $00, $01, $02, $03
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		cart.Mapper = 2
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmBankTableSwitchRegisters(t *testing.T) {
+	input := []byte{
+		0xa2, 0x01, // ldx #$01
+		0xbd, 0x09, 0x80, // lda $8009,X
+		0x8d, 0x00, 0x80, // sta $8000
+		0x40,                   // rti
+		0x00, 0x01, 0x02, 0x03, // bank numbers
+	}
+
+	switched := `Reset:
+ldx #$01
+lda a:bank_table_8009,X
+sta a:Reset                    ; bank switch via lookup table
+rti
+
+bank_table_8009:
+.byte $00, $01, $02, $03
+`
+
+	notSwitched := `Reset:
+ldx #$01
+lda a:_data_8009_indexed,X
+sta a:Reset
+rti
+
+_data_8009_indexed:
+.byte $00, $01, $02, $03
+`
+
+	tests := []struct {
+		mapper   byte
+		expected string
+	}{
+		{0, notSwitched},  // NROM has no registers
+		{3, switched},     // CNROM CHR bank
+		{9, notSwitched},  // MMC2 has no register at $8000
+		{19, switched},    // Namco 163 CHR bank
+		{24, switched},    // VRC6 PRG bank
+		{66, switched},    // GxROM PRG and CHR bank
+		{69, notSwitched}, // FME-7 command register
+	}
+
+	for _, test := range tests {
+		setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+			cart.Mapper = test.mapper
+			opts.OffsetComments = false
+			opts.HexComments = false
+		}
+		runDisasm(t, setup, input, test.expected)
+	}
+}
+
+func TestDisasmWordTable(t *testing.T) {
+	input := []byte{0x40} // rti
+	for i := range 8 {
+		input = append(input, byte(0x11+i), 0x80) // .word $8011 + i
+	}
+	input = append(input, bytes.Repeat([]byte{0x60}, 8)...) // rts
+
+	expected := `Reset:
+        rti
+
+        .word _label_8011
+        .word _label_8012
+        .word _label_8013
+        .word _label_8014
+        .word _label_8015
+        .word _label_8016
+        .word _label_8017
+        .word _label_8018
+
+_label_8011:
+        rts
+
+_label_8012:
+        rts
+
+_label_8013:
+        rts
+
+_label_8014:
+        rts
+
+_label_8015:
+        rts
+
+_label_8016:
+        rts
+
+_label_8017:
+        rts
+
+_label_8018:
+        rts
 `
 
-	setup := func(_ *options.Disassembler, _ *cartridge.Cartridge) {}
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.WordTables = []options.WordTable{{Address: 0x8001, Entries: 8}}
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmAPUWrites(t *testing.T) {
+	input := []byte{
+		0x8d, 0x00, 0x40, // sta $4000
+		0x8e, 0x01, 0x40, // stx $4001
+		0x8d, 0x02, 0x40, // sta $4002
+		0x8c, 0x03, 0x40, // sty $4003
+		0x40, // rti
+	}
+
+	expected := `
+; APU registers
+APU_PL1_HI = $4003
+APU_PL1_LO = $4002
+APU_PL1_SWEEP = $4001
+APU_PL1_VOL = $4000
+
+Reset:
+sta APU_PL1_VOL                ; pulse1 duty/volume
+stx APU_PL1_SWEEP              ; pulse1 sweep
+sta APU_PL1_LO                 ; pulse1 timer lo
+sty APU_PL1_HI                 ; pulse1 timer hi/length
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmDelayLoop(t *testing.T) {
+	input := []byte{
+		0xa2, 0x10, // ldx #$10
+		0xa0, 0x00, // ldy #$00
+		0x88,       // dey
+		0xd0, 0xfd, // bne $8004
+		0xca,       // dex
+		0xd0, 0xf8, // bne $8002
+		0x40, // rti
+	}
+
+	expected := `Reset:
+        ldx #$10
+
+_label_8002:
+        ldy #$00                       ; busy-wait / delay loop
+
+_label_8004:
+        dey
+        bne _label_8004                ; 256 iterations
+        dex
+        bne _label_8002                ; 16 iterations
+        rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmDelayLoopSearchRejected(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xbd, 0x00, 0x03, // lda $0300,X
+		0xf0, 0x05, // beq $800c
+		0xe8,       // inx
+		0xe0, 0x08, // cpx #$08
+		0xd0, 0xf6, // bne $8002
+		0x40, // rti
+	}
+
+	expected := `
+_var_0300_indexed = $0300
+
+Reset:
+ldx #$00
+
+_label_8002:
+lda a:_var_0300_indexed,X
+beq _label_800c
+inx
+cpx #$08
+bne _label_8002
+
+_label_800c:
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmProvenance(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.Provenance = true
+	opts.SourceFile = "game.nes"
+	disasm := testProgram(t, opts, cartridge.New(), []byte{0x40}) // rti
+
+	var buffer bytes.Buffer
+	app, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+
+	expected := fmt.Sprintf("; Source: game.nes (CRC32 %08x)\n", app.Checksums.Overall)
+	assert.True(t, strings.HasPrefix(buffer.String(), expected))
+}
+
+func TestDisasmAPUSilence(t *testing.T) {
+	input := []byte{
+		0xa9, 0x00, // lda #$00
+		0x8d, 0x15, 0x40, // sta $4015
+		0x40, // rti
+	}
+
+	expected := `
+; APU registers
+APU_SND_CHN = $4015
+
+Reset:
+lda #$00
+sta APU_SND_CHN                ; silence APU
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmOpenBusReads(t *testing.T) {
+	input := []byte{
+		0xad, 0x00, 0x50, // lda $5000
+		0xad, 0x18, 0x40, // lda $4018
+		0xad, 0x00, 0x60, // lda $6000
+		0x40, // rti
+	}
+
+	expected := `Reset:
+lda a:$5000                    ; open bus / unmapped read
+lda a:$4018                    ; open bus / unmapped read
+lda a:$6000
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmOpenBusReadsExpansionArea(t *testing.T) {
+	input := []byte{
+		0xad, 0x30, 0x40, // lda $4030
+		0xad, 0x00, 0x41, // lda $4100
+		0x40, // rti
+	}
+
+	mapped := `Reset:
+lda a:$4030
+lda a:$4100
+rti
+`
+
+	unmapped := `Reset:
+lda a:$4030                    ; open bus / unmapped read
+lda a:$4100                    ; open bus / unmapped read
+rti
+`
+
+	tests := []struct {
+		mapper   byte
+		expected string
+	}{
+		{0, unmapped},  // NROM
+		{4, unmapped},  // MMC3
+		{20, mapped},   // Famicom Disk System
+		{79, mapped},   // NINA-03/NINA-06
+		{113, mapped},  // NINA-03/NINA-06 multicart
+		{146, mapped},  // Sachen 3015
+		{163, mapped},  // Nanjing
+		{66, unmapped}, // GxROM
+	}
+
+	for _, test := range tests {
+		setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+			cart.Mapper = test.mapper
+			opts.OffsetComments = false
+			opts.HexComments = false
+		}
+		runDisasm(t, setup, input, test.expected)
+	}
+}
+
+func TestDisasmFunctionMetrics(t *testing.T) {
+	input := []byte{
+		0x20, 0x04, 0x80, // jsr $8004
+		0x40,       // rti
+		0xa2, 0x03, // ldx #$03
+		0xca,       // dex
+		0xd0, 0xfd, // bne $8006
+		0xf0, 0x00, // beq $800b
+		0x60, // rts
+	}
+
+	expected := `Reset:                           ; 2 instrs, 0 branches
+jsr _func_8004
+rti
+
+_func_8004:                      ; 5 instrs, 2 branches
+ldx #$03
+
+_label_8006:
+dex
+bne _label_8006                ; 3 iterations
+beq _label_800b
+
+_label_800b:
+rts
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.FunctionMetrics = true
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmNOPSled(t *testing.T) {
+	input := []byte{
+		0xea, // nop
+		0xea, // nop
+		0xea, // nop
+		0x40, // rti
+	}
+
+	expected := `Reset:
+nop                            ; 6-cycle delay
+nop
+nop
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmBankCrossingWarnings(t *testing.T) {
+	input := []byte{
+		0x4c, 0x00, 0xc0, // jmp $c000
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.BankCrossingWarnings = true
+	opts.OffsetComments = false
+	opts.HexComments = false
+	cart := cartridge.New()
+	cart.PRG = make([]byte, 0x10000)
+	cart.PRG[0xc000] = 0x40 // rti
+	cart.PRG[0xfffd] = 0x80
+	disasm := testProgram(t, opts, cart, input)
+
+	// the jump destination is mapped from the second bank, the comment is checked directly
+	// to avoid outputting the data of both banks
+	app, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "branch/jump crosses bank window", app.PRG[0].Offsets[0].Comment)
+}
+
+func TestDisasmFileOffsets(t *testing.T) {
+	input := []byte{
+		0x4c, 0x00, 0xc0, // jmp $c000
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.FileOffsets = true
+	opts.OffsetComments = false
+	opts.HexComments = false
+	cart := cartridge.New()
+	cart.PRG = make([]byte, 0x10000)
+	cart.PRG[0xc000] = 0x40 // rti
+	cart.PRG[0xfffd] = 0x80
+	disasm := testProgram(t, opts, cart, input)
+
+	app, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "file $00010", app.PRG[0].Offsets[0].Comment)
+	// the jump destination is mapped from the second bank, the offset includes the iNES header
+	assert.Equal(t, "file $0C010", app.PRG[1].Offsets[0x4000].Comment)
+}
+
+func TestDisasmBankChecksums(t *testing.T) {
+	input := []byte{
+		0x4c, 0x00, 0xc0, // jmp $c000
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	opts.BankChecksums = true
+	opts.CodeOnly = true
+	cart := cartridge.New()
+	cart.PRG = make([]byte, 0x10000)
+	cart.PRG[0xc000] = 0x40 // rti
+	cart.PRG[0xfffd] = 0x80
+	disasm := testProgram(t, opts, cart, input)
+
+	var buffer bytes.Buffer
+	app, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+	assert.Len(t, app.PRG, 2)
+
+	output := buffer.String()
+	for i, bank := range app.PRG {
+		checksum := crc32.ChecksumIEEE(cart.PRG[i*0x8000 : (i+1)*0x8000])
+		assert.Equal(t, checksum, bank.Checksum)
+		assert.True(t, strings.Contains(output, fmt.Sprintf("; bank CRC32: %08X\n", checksum)))
+	}
+}
+
+func TestDisasmNodes(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0xf0, 0xfb, // beq $8000
+		0x40, // rti
+		0xea, // data
+		0x60, // rts
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	disasm := testProgram(t, opts, cartridge.New(), input)
+	_, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+
+	expected := []mapper.Node{
+		{Address: 0x8000, Successors: []uint16{0x8003, 0x8007}, Call: true},
+		{Address: 0x8003, Successors: []uint16{0x8000, 0x8005}},
+		{Address: 0x8005},
+		{Address: 0x8007},
+	}
+	assert.Equal(t, expected, disasm.Nodes())
+}
+
+func TestDisasmFrameFlagWait(t *testing.T) {
+	input := []byte{
+		0xa5, 0x10, // lda $10
+		0xf0, 0xfc, // beq $8000
+		0xa9, 0x00, // lda #$00
+		0x85, 0x10, // sta $10
+		0x4c, 0x00, 0x80, // jmp $8000
+		0xe6, 0x10, // nmi: inc $10
+		0x40, // rti
+	}
+
+	expected := `
+frame_ready_flag = $0010
+
+Reset:
+lda z:frame_ready_flag         ; wait for NMI frame flag
+beq Reset
+lda #$00
+sta z:frame_ready_flag
+jmp Reset
+
+NMI:
+inc z:frame_ready_flag
+rti
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+
+		cart.PRG[0x7ffa] = 0x0b
+		cart.PRG[0x7ffb] = 0x80
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmRAMFillLoop(t *testing.T) {
+	input := []byte{
+		0xa2, 0x00, // ldx #$00
+		0xa9, 0xff, // lda #$ff
+		0x9d, 0x00, 0x03, // sta $0300,X
+		0x9d, 0x00, 0x04, // sta $0400,X
+		0xe8,       // inx
+		0xe0, 0x20, // cpx #$20
+		0xd0, 0xf5, // bne $8004
+		0xad, 0x10, 0x03, // lda $0310
+		0x40, // rti
+	}
+
+	expected := `
+ram_buffer_0300 = $0300
+ram_buffer_0400 = $0400
+
+Reset:
+ldx #$00
+lda #$FF
+
+_label_8004:
+sta a:ram_buffer_0300,X        ; fill buffer with $FF, size 32
+sta a:ram_buffer_0400,X        ; fill buffer with $FF, size 32
+inx
+cpx #$20
+bne _label_8004                ; 32 iterations
+lda a:ram_buffer_0300+16
+rti
+`
+
+	setup := func(opts *options.Disassembler, _ *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+	}
+	runDisasm(t, setup, input, expected)
+}
+
+func TestDisasmInterruptRegisterSaves(t *testing.T) {
+	input := []byte{
+		0x4c, 0x00, 0x80, // jmp $8000
+		0x48,       // irq: pha
+		0x8a,       // txa
+		0x48,       // pha
+		0x98,       // tya
+		0x48,       // pha
+		0xe6, 0x10, // inc $10
+		0x68, // pla
+		0xa8, // tay
+		0x68, // pla
+		0xaa, // tax
+		0x68, // pla
+		0x40, // rti
+	}
+
+	expected := `
+_var_0010 = $0010
 
+Reset:
+jmp Reset
+
+IRQ:
+pha                            ; save registers
+txa
+pha
+tya
+pha
+inc z:_var_0010
+pla                            ; restore registers
+tay
+pla
+tax
+pla
+rti
+`
+
+	setup := func(opts *options.Disassembler, cart *cartridge.Cartridge) {
+		opts.OffsetComments = false
+		opts.HexComments = false
+
+		cart.PRG[0x7ffe] = 0x03
+		cart.PRG[0x7fff] = 0x80
+	}
 	runDisasm(t, setup, input, expected)
 }
 
+func TestDisasmCountedLoop(t *testing.T) {
+	input := []byte{
+		0xa2, 0x08, // ldx #$08
+		0xa9, 0x00, // lda #$00
+		0x95, 0x10, // sta $10,X
+		0xca,       // dex
+		0xd0, 0xfb, // bne $8004
+		0x40, // rti
+	}
+
+	expected := `
+ram_buffer_0010 = $0010
+
+Reset:
+ldx #$08
+lda #$00
+
+_label_8004:
+sta z:ram_buffer_0010,X        ; fill buffer with $00, size 8
+dex
+bne _label_8004                ; 8 iterations
+rti
+`
+	runDisasm(t, nil, input, expected)
+}
+
+func TestDisasmAsm6BankVectors(t *testing.T) {
+	opts := options.NewDisassembler(assembler.Asm6)
+	cart := cartridge.New()
+	cart.PRG = make([]byte, 0x10000)
+	cart.PRG[0] = 0x40 // rti
+	// vectors of the first bank point to the reset handler in the same bank
+	copy(cart.PRG[0x7ffa:], []byte{0x00, 0x80, 0x00, 0x80, 0x00, 0x80})
+	cart.PRG[0xfffd] = 0x80
+
+	ar := m6502.New(asm6.Syntax)
+	logger := log.NewTestLogger(t)
+	disasm, err := New(ar, logger, cart, opts, asm6.New)
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	_, err = disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(buffer.String(), ".dw Reset, Reset, Reset\n"), "bank vectors should reference labels")
+}
+
+func TestDisasmNES2Header(t *testing.T) {
+	input := []byte{
+		0x40, // rti
+	}
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	// NES 2.0 header of a Vs. System ROM with PAL timing
+	opts.INESHeader = []byte{'N', 'E', 'S', 0x1a, 0x02, 0x01, 0x00, 0x09, 0x10, 0x00, 0x07, 0x00, 0x01, 0x02, 0x03, 0x04}
+	disasm := testProgram(t, opts, cartridge.New(), input)
+
+	var buffer bytes.Buffer
+	_, err := disasm.Process(context.Background(), &buffer, nil)
+	assert.NoError(t, err)
+
+	output := buffer.String()
+	assert.True(t, strings.Contains(output, ".byte $09                        ; Control bits 2, Vs. System\n"))
+	assert.True(t, strings.Contains(output, ".byte $07                        ; PRG-RAM size shift count\n"))
+	assert.True(t, strings.Contains(output, ".byte $01                        ; PAL timing\n"))
+	assert.True(t, strings.Contains(output, ".byte $10                        ; Mapper MSB and submapper\n"))
+	assert.True(t, strings.Contains(output, ".byte $02                        ; Vs. System PPU and hardware type\n"))
+	assert.True(t, strings.Contains(output, ".byte $03                        ; Number of miscellaneous ROMs\n"))
+	assert.True(t, strings.Contains(output, ".byte $04                        ; Default expansion device\n"))
+}
+
+func TestDisasmNoPRGBanks(t *testing.T) {
+	header := []byte{'N', 'E', 'S', 0x1a, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	header = append(header, make([]byte, 0x2000)...)
+	cart, err := cartridge.LoadFile(bytes.NewReader(header))
+	assert.NoError(t, err)
+
+	ar := m6502.New(ca65.Syntax)
+	logger := log.NewTestLogger(t)
+	opts := options.NewDisassembler(assembler.Ca65)
+	_, err = New(ar, logger, cart, opts, ca65.New)
+	assert.Error(t, err, "cartridge does not contain any PRG-ROM banks")
+}
+
+func TestDisasmLabelsFile(t *testing.T) {
+	input := []byte{
+		0x20, 0x07, 0x80, // jsr $8007
+		0xbd, 0x00, 0x03, // lda a:$0300,X
+		0x40, // rti
+		0x60, // rts
+	}
+
+	expected := `$8000 Reset
+$8007 _func_8007
+$0300 _var_0300_indexed
+`
+
+	opts := options.NewDisassembler(assembler.Ca65)
+	cart := cartridge.New()
+	disasm := testProgram(t, opts, cart, input)
+	// the assembly file writer must not be used when only the labels are written
+	disasm.fileWriterConstructor = nil
+
+	app, err := disasm.Disassemble(context.Background())
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, symbols.Write(app, &buffer))
+	assert.Equal(t, expected, buffer.String())
+}
+
 func testProgram(t *testing.T, options options.Disassembler, cart *cartridge.Cartridge, code []byte) *Disasm {
 	t.Helper()
 
@@ -316,7 +3597,7 @@ func testProgram(t *testing.T, options options.Disassembler, cart *cartridge.Car
 
 	copy(cart.PRG, code)
 
-	ar := m6502.New(parameter.New(ca65.ParamConfig))
+	ar := m6502.New(ca65.Syntax)
 	logger := log.NewTestLogger(t)
 	disasm, err := New(ar, logger, cart, options, ca65.New)
 	assert.NoError(t, err)
@@ -324,6 +3605,14 @@ func testProgram(t *testing.T, options options.Disassembler, cart *cartridge.Car
 	return disasm
 }
 
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
 func trimStringList(s string) string {
 	sl := strings.Split(s, "\n")
 	for i, s := range sl {
@@ -357,7 +3646,7 @@ func runDisasm(t *testing.T, setup func(options *options.Disassembler, cart *car
 		return nil, nil // nolint: nilnil
 	}
 
-	app, err := disasm.Process(writer, newBankWriter)
+	app, err := disasm.Process(context.Background(), writer, newBankWriter)
 	assert.NoError(t, err)
 	assert.True(t, app != nil, "app should not be nil")
 
@@ -0,0 +1,112 @@
+package disasm
+
+import (
+	"fmt"
+	"hash/crc32"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// functionInstructionBytes returns the start addresses of all functions in order of their address
+// and the instruction bytes of every function.
+func (dis *Disasm) functionInstructionBytes() ([]uint16, map[uint16][]byte) {
+	var functions []uint16
+	functionBytes := map[uint16][]byte{}
+
+	lastCodeAddress := uint32(dis.arch.LastCodeAddress())
+	for address := uint32(dis.codeBaseAddress); address < lastCodeAddress; address++ {
+		offsetInfo := dis.mapper.OffsetInfo(uint16(address))
+		if offsetInfo == nil || !offsetInfo.IsType(program.CodeOffset) || len(offsetInfo.Data) == 0 {
+			continue
+		}
+
+		context := offsetInfo.Context
+		if context == 0 {
+			continue
+		}
+		if _, ok := functionBytes[context]; !ok {
+			functions = append(functions, context)
+		}
+		functionBytes[context] = append(functionBytes[context], offsetInfo.Data...)
+	}
+	return functions, functionBytes
+}
+
+// detectIdenticalFunctions detects functions that consist of the same instruction bytes and
+// annotates the label of every duplicate with the name of the first function.
+// The function labels have to be set before calling this function.
+func (dis *Disasm) detectIdenticalFunctions() {
+	functions, functionBytes := dis.functionInstructionBytes()
+
+	firstFunction := map[string]uint16{}
+	for _, address := range functions {
+		offsetInfo := dis.mapper.OffsetInfo(address)
+		if !offsetInfo.IsType(program.CallDestination) || offsetInfo.Label == "" {
+			continue
+		}
+
+		data := string(functionBytes[address])
+		first, ok := firstFunction[data]
+		if !ok {
+			firstFunction[data] = address
+			continue
+		}
+
+		offsetInfo.AddLabelComment("identical to " + dis.mapper.OffsetInfo(first).Label)
+	}
+}
+
+// detectKnownFunctions matches the CRC32 checksum of the instruction bytes of every function against
+// the known functions database and annotates all matching functions. The first matching function of
+// every checksum gets labeled with the name of the known function, unless it already has a label,
+// its generated label is renamed by the rename map or the name is already used by another label.
+// It has to be called before the jump destination labels are generated.
+func (dis *Disasm) detectKnownFunctions() {
+	functions, functionBytes := dis.functionInstructionBytes()
+
+	usedNames := map[string]struct{}{}
+	for _, name := range dis.options.Renames {
+		usedNames[name] = struct{}{}
+	}
+	for _, address := range functions {
+		if label := dis.mapper.OffsetInfo(address).Label; label != "" {
+			usedNames[label] = struct{}{}
+		}
+	}
+
+	crc32q := crc32.MakeTable(crc32.IEEE)
+	labeled := map[uint32]struct{}{}
+
+	for _, address := range functions {
+		offsetInfo := dis.mapper.OffsetInfo(address)
+		if !offsetInfo.IsType(program.CallDestination) {
+			continue
+		}
+
+		crc := crc32.Checksum(functionBytes[address], crc32q)
+		known, ok := dis.options.KnownFunctions[crc]
+		if !ok {
+			continue
+		}
+
+		comment := "recognized: " + known.Name
+		if known.Library != "" {
+			comment = fmt.Sprintf("%s from %s", comment, known.Library)
+		}
+		offsetInfo.AddLabelComment(comment)
+
+		if _, ok := labeled[crc]; ok {
+			continue
+		}
+		labeled[crc] = struct{}{}
+
+		if _, ok := usedNames[known.Name]; ok || offsetInfo.Label != "" {
+			continue
+		}
+		if _, ok := dis.options.Renames[autoLabelName(address, offsetInfo)]; ok {
+			continue
+		}
+		usedNames[known.Name] = struct{}{}
+		offsetInfo.Label = known.Name
+	}
+}
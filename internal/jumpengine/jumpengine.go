@@ -13,10 +13,16 @@ var _ arch.JumpEngine = &JumpEngine{}
 
 const jumpEngineLastInstructionsCheck = 16
 
+const selfReferenceComment = "suspicious self-reference"
+
+const jumpEngineComment = "jump engine detected"
+
 // jumpEngineCaller stores info about a caller of a jump engine, which is followed by a list of function addresses
 type jumpEngineCaller struct {
-	entries           int  // count of referenced functions in the table
-	terminated        bool // marks whether the end of the table has been found
+	entries           int    // count of referenced functions in the table
+	maxEntries        int    // count of table entries determined by an index bounds check, 0 if unknown
+	terminated        bool   // marks whether the end of the table has been found
+	engine            uint16 // address of the jump engine that reads the table, 0 for a table following a call
 	tableStartAddress uint16
 }
 
@@ -43,9 +49,10 @@ func (j *JumpEngine) AddJumpEngine(address uint16) {
 }
 
 // GetFunctionTableReference detects a jump engine function context and its function table.
+// The number of table entries is used to limit the table size if it is known, 0 otherwise.
 // TODO use jump address as key to be able to handle large function
 // contexts containing multiple jump engines
-func (j *JumpEngine) GetFunctionTableReference(context uint16, dataReferences []uint16) {
+func (j *JumpEngine) GetFunctionTableReference(context uint16, dataReferences []uint16, tableEntries int) {
 	// if there are multiple data references just look at the last 2
 	if len(dataReferences) > 2 {
 		dataReferences = dataReferences[len(dataReferences)-2:]
@@ -61,13 +68,16 @@ func (j *JumpEngine) GetFunctionTableReference(context uint16, dataReferences []
 		smallestReference = dataReferences[1]
 	}
 
-	// the access to the function tables can be done using the same address and an incremented x register
-	// or to an incremented address and the same x register
+	// the access to the function tables can be done using the same address and an incremented x or y
+	// register or to an incremented address and the same index register
 	if referenceDistance != 0 && referenceDistance != 1 {
 		return
 	}
 
-	jumpEngine := &jumpEngineCaller{}
+	jumpEngine := &jumpEngineCaller{
+		maxEntries: tableEntries,
+		engine:     context,
+	}
 	j.jumpEngineCallersAdded[context] = jumpEngine
 	j.jumpEngineCallers = append(j.jumpEngineCallers, jumpEngine)
 
@@ -80,7 +90,7 @@ func (j *JumpEngine) GetContextDataReferences(dis arch.Disasm, offsets []*arch.O
 	addresses []uint16) ([]uint16, error) {
 
 	codeBaseAddress := dis.CodeBaseAddress()
-	var dataReferences []uint16
+	var dataReferences, indexedReferences []uint16
 
 	for i, offsetInfoInstruction := range offsets {
 		address := addresses[i]
@@ -98,9 +108,21 @@ func (j *JumpEngine) GetContextDataReferences(dis arch.Disasm, offsets []*arch.O
 		}
 
 		reference, ok := j.arch.GetAddressingParam(param)
-		if ok && reference >= codeBaseAddress && reference < j.arch.LastCodeAddress() {
-			dataReferences = append(dataReferences, reference)
+		if !ok || reference < codeBaseAddress || reference >= j.arch.LastCodeAddress() {
+			continue
 		}
+
+		dataReferences = append(dataReferences, reference)
+		if j.arch.IsAddressingIndexed(opcode) {
+			indexedReferences = append(indexedReferences, reference)
+		}
+	}
+
+	// the function table is accessed using either index register, with the same base address and an
+	// incremented index or with an incremented base address and the same index. other reads in the
+	// context are ignored if both table reads have been found.
+	if len(indexedReferences) > 1 {
+		return indexedReferences, nil
 	}
 	return dataReferences, nil
 }
@@ -137,10 +159,8 @@ func (j *JumpEngine) JumpContextInfo(dis arch.Disasm, jumpAddress uint16, offset
 
 // HandleJumpEngineDestination processes a newly detected jump engine destination.
 func (j *JumpEngine) HandleJumpEngineDestination(dis arch.Disasm, caller, destination uint16) error {
-	for addr := range j.jumpEngines {
-		if addr == destination {
-			return j.HandleJumpEngineCallers(dis, caller)
-		}
+	if _, ok := j.jumpEngines[destination]; ok {
+		return j.HandleJumpEngineCallers(dis, caller)
 	}
 	return nil
 }
@@ -148,7 +168,7 @@ func (j *JumpEngine) HandleJumpEngineDestination(dis arch.Disasm, caller, destin
 // HandleJumpEngineCallers processes all callers of a newly detected jump engine function.
 func (j *JumpEngine) HandleJumpEngineCallers(dis arch.Disasm, context uint16) error {
 	offsetInfo := dis.Mapper().OffsetInfo(context)
-	offsetInfo.LabelComment = "jump engine detected"
+	offsetInfo.LabelComment = jumpEngineComment
 	offsetInfo.SetType(program.JumpEngine)
 
 	for _, ref := range offsetInfo.BranchFrom {
@@ -187,29 +207,70 @@ func (j *JumpEngine) processJumpEngineEntry(dis arch.Disasm, address uint16, jum
 	if jumpEngine.terminated {
 		return false, nil
 	}
+	if jumpEngine.maxEntries > 0 && jumpEngine.entries >= jumpEngine.maxEntries {
+		jumpEngine.terminated = true
+		return false, nil
+	}
+
+	valid, err := j.validTableEntry(dis, address)
+	if err != nil {
+		return false, err
+	}
+	if valid && jumpEngine.entries == 0 {
+		if valid, err = j.hasMinimumEntries(dis, address, jumpEngine); err != nil {
+			return false, err
+		}
+	}
+	if !valid {
+		j.terminateTable(dis, jumpEngine)
+		return false, nil
+	}
 
-	// verify that the destination is in valid code address range
 	destination, err := dis.ReadMemoryWord(address)
 	if err != nil {
 		return false, fmt.Errorf("reading memory word: %w", err)
 	}
-	codeBaseAddress := dis.CodeBaseAddress()
-	if destination < codeBaseAddress || destination >= j.arch.LastCodeAddress() {
-		jumpEngine.terminated = true
+
+	// a pointer into the table itself is most likely data that follows the table
+	if destination >= jumpEngine.tableStartAddress && destination <= address+1 {
+		dis.Mapper().OffsetInfo(address).AddComment(selfReferenceComment)
+		j.terminateTable(dis, jumpEngine)
 		return false, nil
 	}
 
+	if err := setFunctionReference(dis, address, jumpEngine.entries == 0); err != nil {
+		return false, err
+	}
+	jumpEngine.entries++
+
+	dis.AddAddressToParse(destination, destination, address, nil, true)
+	return true, nil
+}
+
+// terminateTable marks the end of the table of the jump engine caller. If the table of a jump
+// engine does not contain any function reference, the function is not marked as jump engine.
+func (j *JumpEngine) terminateTable(dis arch.Disasm, jumpEngine *jumpEngineCaller) {
+	jumpEngine.terminated = true
+	if jumpEngine.entries > 0 || jumpEngine.engine == 0 {
+		return
+	}
+
+	delete(j.jumpEngines, jumpEngine.engine)
+	offsetInfo := dis.Mapper().OffsetInfo(jumpEngine.engine)
+	offsetInfo.ClearType(program.JumpEngine)
+	if offsetInfo.LabelComment == jumpEngineComment {
+		offsetInfo.LabelComment = ""
+	}
+}
+
+// setFunctionReference marks the word at the given address as function reference, the first entry
+// of a table is also marked as jump table.
+func setFunctionReference(dis arch.Disasm, address uint16, firstEntry bool) error {
 	mapper := dis.Mapper()
 	offsetInfo1 := mapper.OffsetInfo(address)
 	offsetInfo2 := mapper.OffsetInfo(address + 1)
 
-	// if the potential jump table entry is already marked as code, the table end is reached
-	if offsetInfo1.Type == program.CodeOffset || offsetInfo2.Type == program.CodeOffset {
-		jumpEngine.terminated = true
-		return false, nil
-	}
-
-	if jumpEngine.entries == 0 {
+	if firstEntry {
 		offsetInfo1.SetType(program.JumpTable)
 	}
 
@@ -218,19 +279,56 @@ func (j *JumpEngine) processJumpEngineEntry(dis arch.Disasm, address uint16, jum
 
 	b1, err := dis.ReadMemory(address)
 	if err != nil {
-		return false, fmt.Errorf("reading memory: %w", err)
+		return fmt.Errorf("reading memory: %w", err)
 	}
 	b2, err := dis.ReadMemory(address + 1)
 	if err != nil {
-		return false, fmt.Errorf("reading memory: %w", err)
+		return fmt.Errorf("reading memory: %w", err)
 	}
 
 	offsetInfo1.Data = []byte{b1, b2}
 	offsetInfo2.Data = nil
+	return nil
+}
 
-	jumpEngine.entries++
+// validTableEntry returns whether the word at the given address can be a function reference
+// of a jump engine table.
+func (j *JumpEngine) validTableEntry(dis arch.Disasm, address uint16) (bool, error) {
+	// verify that the destination is in valid code address range
+	destination, err := dis.ReadMemoryWord(address)
+	if err != nil {
+		return false, fmt.Errorf("reading memory word: %w", err)
+	}
+	if destination < dis.CodeBaseAddress() || destination >= j.arch.LastCodeAddress() {
+		return false, nil
+	}
 
-	dis.AddAddressToParse(destination, destination, address, nil, true)
+	// if the potential jump table entry is already marked as code, the table end is reached
+	mapper := dis.Mapper()
+	offsetInfo1 := mapper.OffsetInfo(address)
+	offsetInfo2 := mapper.OffsetInfo(address + 1)
+	return offsetInfo1.Type != program.CodeOffset && offsetInfo2.Type != program.CodeOffset, nil
+}
+
+// hasMinimumEntries returns whether the table starting at the given address contains at least the
+// configured minimum number of valid function references. This avoids that unrelated data loads
+// that look like a table access result in data being processed as function references.
+func (j *JumpEngine) hasMinimumEntries(dis arch.Disasm, address uint16, jumpEngine *jumpEngineCaller) (bool, error) {
+	minEntries := dis.Options().MinJumpTableEntries
+	if jumpEngine.maxEntries > 0 && jumpEngine.maxEntries < minEntries {
+		return false, nil
+	}
+
+	for i := 1; i < minEntries; i++ {
+		entryAddress := int(address) + 2*i
+		if entryAddress+1 >= int(j.arch.LastCodeAddress()) {
+			return false, nil
+		}
+		valid, err := j.validTableEntry(dis, uint16(entryAddress))
+		if err != nil || !valid {
+			return false, err
+		}
+	}
 	return true, nil
 }
 
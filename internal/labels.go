@@ -0,0 +1,73 @@
+package disasm
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// removeAutoLabels removes all automatically generated label and variable names from the program
+// and replaces all references to them by their raw address. Only the interrupt handler names and
+// names from the rename map are kept.
+func (dis *Disasm) removeAutoLabels(app *program.Program) {
+	userLabels := map[string]struct{}{
+		app.Handlers.NMI:   {},
+		app.Handlers.Reset: {},
+		app.Handlers.IRQ:   {},
+	}
+	for _, name := range dis.options.Renames {
+		userLabels[name] = struct{}{}
+	}
+	for _, known := range dis.options.KnownFunctions {
+		userLabels[known.Name] = struct{}{}
+	}
+
+	addresses := map[string]string{}
+	removeAutoVariables(app.Variables, userLabels, addresses)
+
+	for _, bank := range app.PRG {
+		removeAutoVariables(bank.Variables, userLabels, addresses)
+
+		for i, offset := range bank.Offsets {
+			if offset.Label == "" {
+				continue
+			}
+			if _, ok := userLabels[offset.Label]; ok {
+				continue
+			}
+
+			addresses[offset.Label] = fmt.Sprintf("$%04X", offset.Address)
+			bank.Offsets[i].Label = ""
+			if offset.LabelComment != "" {
+				bank.Offsets[i].Comment = program.JoinComments(offset.LabelComment, offset.Comment)
+				bank.Offsets[i].LabelComment = ""
+			}
+		}
+	}
+
+	for _, bank := range app.PRG {
+		for i, offset := range bank.Offsets {
+			if offset.Code == "" {
+				continue
+			}
+			bank.Offsets[i].Code = program.ReplaceSymbols(offset.Code, func(symbol string) string {
+				if address, ok := addresses[symbol]; ok {
+					return address
+				}
+				return symbol
+			})
+		}
+	}
+}
+
+// removeAutoVariables removes all variables that are not user labels from the variables map
+// and adds their raw address to the addresses map.
+func removeAutoVariables(variables map[string]uint16, userLabels map[string]struct{}, addresses map[string]string) {
+	for name, address := range variables {
+		if _, ok := userLabels[name]; ok {
+			continue
+		}
+		addresses[name] = fmt.Sprintf("$%04X", address)
+		delete(variables, name)
+	}
+}
@@ -3,6 +3,8 @@ package mapper
 
 import (
 	"fmt"
+	"hash/crc32"
+	"slices"
 	"strings"
 
 	"github.com/retroenv/nesgodisasm/internal/arch"
@@ -106,6 +108,17 @@ func (m *Mapper) OffsetInfo(address uint16) *arch.Offset {
 	return offsetInfo
 }
 
+// PRGOffsetInfo returns the offset information for the given PRG ROM offset.
+func (m *Mapper) PRGOffsetInfo(offset int) *arch.Offset {
+	window := offset / m.bankWindowSize
+	if offset < 0 || window >= len(m.banksMapped) {
+		return nil
+	}
+
+	index := offset % m.bankWindowSize
+	return m.banksMapped[window].OffsetInfo(uint16(index))
+}
+
 // ProcessData sets all data bytes for offsets that have not being identified as code.
 func (m *Mapper) ProcessData() {
 	for _, bnk := range m.banks {
@@ -122,22 +135,25 @@ func (m *Mapper) ProcessData() {
 	}
 }
 func (m *Mapper) SetProgramBanks(dis arch.Disasm, app *program.Program) error {
+	var bankStart int
 	for bnkIndex, bnk := range m.banks {
 		prgBank := program.NewPRGBank(len(bnk.offsets))
 
 		for i := range len(bnk.offsets) {
 			offsetInfo := bnk.offsets[i]
-			programOffsetInfo, err := getProgramOffset(dis, dis.CodeBaseAddress()+uint16(i), offsetInfo)
+			programOffsetInfo, err := getProgramOffset(dis, dis.CodeBaseAddress()+uint16(i), bankStart+i, offsetInfo)
 			if err != nil {
 				return err
 			}
 
 			prgBank.Offsets[i] = programOffsetInfo
 		}
+		bankStart += len(bnk.offsets)
 
 		dis.Constants().SetBankConstants(bnkIndex, prgBank)
 		dis.Variables().SetBankVariables(bnkIndex, prgBank)
 
+		prgBank.Checksum = crc32.ChecksumIEEE(bnk.prg)
 		setBankName(prgBank, bnkIndex, len(m.banks))
 		setBankVectors(bnk, prgBank)
 
@@ -146,6 +162,84 @@ func (m *Mapper) SetProgramBanks(dis arch.Disasm, app *program.Program) error {
 	return nil
 }
 
+// Coverage contains the number of PRG bytes for every classification.
+type Coverage struct {
+	Code       int // bytes of decoded instructions
+	CodeAsData int // bytes of decoded instructions that are forced to be output as data
+	Data       int // bytes that are not code
+	Excluded   int // bytes that are forced to be data by an excluded address range
+}
+
+// Total returns the number of all classified PRG bytes.
+func (c Coverage) Total() int {
+	return c.Code + c.CodeAsData + c.Data + c.Excluded
+}
+
+// Coverage returns the classification statistics of all PRG bytes.
+func (m *Mapper) Coverage(dis arch.Disasm) Coverage {
+	var coverage Coverage
+	opts := dis.Options()
+
+	for _, bnk := range m.banks {
+		for i, offsetInfo := range bnk.offsets {
+			switch {
+			case offsetInfo.IsType(program.CodeOffset):
+				coverage.Code++
+			case offsetInfo.IsType(program.CodeAsData):
+				coverage.CodeAsData++
+			case opts.Excluded(dis.CodeBaseAddress() + uint16(i)):
+				coverage.Excluded++
+			default:
+				coverage.Data++
+			}
+		}
+	}
+	return coverage
+}
+
+// OpcodeCount contains the number of decoded instructions that use an opcode.
+type OpcodeCount struct {
+	Opcode      byte
+	Instruction string
+	Count       int
+}
+
+// OpcodeHistogram returns the number of decoded instructions per opcode of all PRG bytes,
+// sorted by descending count and ascending opcode.
+func (m *Mapper) OpcodeHistogram() []OpcodeCount {
+	counts := map[byte]*OpcodeCount{}
+	for _, bnk := range m.banks {
+		for _, offsetInfo := range bnk.offsets {
+			if !offsetInfo.IsType(program.CodeOffset) || len(offsetInfo.Data) == 0 || offsetInfo.Opcode == nil {
+				continue
+			}
+
+			opcode := offsetInfo.Data[0]
+			count, ok := counts[opcode]
+			if !ok {
+				count = &OpcodeCount{
+					Opcode:      opcode,
+					Instruction: offsetInfo.Opcode.Instruction().Name(),
+				}
+				counts[opcode] = count
+			}
+			count.Count++
+		}
+	}
+
+	histogram := make([]OpcodeCount, 0, len(counts))
+	for _, count := range counts {
+		histogram = append(histogram, *count)
+	}
+	slices.SortFunc(histogram, func(a, b OpcodeCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return int(a.Opcode) - int(b.Opcode)
+	})
+	return histogram
+}
+
 func (m *Mapper) ApplyCodeDataLog(dis arch.Disasm, prgFlags []codedatalog.PrgFlag) {
 	bank0 := m.banks[0]
 	for index, flags := range prgFlags {
@@ -153,21 +247,42 @@ func (m *Mapper) ApplyCodeDataLog(dis arch.Disasm, prgFlags []codedatalog.PrgFla
 			return
 		}
 
+		address := dis.CodeBaseAddress() + uint16(index)
+		if dis.Options().Excluded(address) {
+			continue // excluded address ranges are always data
+		}
+
 		if flags&codedatalog.Code != 0 {
-			dis.AddAddressToParse(dis.CodeBaseAddress()+uint16(index), 0, 0, nil, false)
+			dis.AddAddressToParse(address, 0, 0, nil, false)
 		}
 		if flags&codedatalog.SubEntryPoint != 0 {
 			bank0.offsets[index].SetType(program.CallDestination)
+			if dis.Options().SeedFromCDLCalls {
+				dis.AddAddressToParse(address, address, 0, nil, true)
+			}
 		}
 	}
 }
 
-func getProgramOffset(dis arch.Disasm, address uint16, offsetInfo *arch.Offset) (program.Offset, error) {
+func getProgramOffset(dis arch.Disasm, address uint16, prgOffset int, offsetInfo *arch.Offset) (program.Offset, error) {
 	programOffset := offsetInfo.Offset
 	programOffset.Address = address
+	if name, ok := dis.Options().Renames[programOffset.Label]; ok {
+		programOffset.Label = name
+	}
 
 	if offsetInfo.BranchingTo != "" {
-		programOffset.Code = fmt.Sprintf("%s %s", offsetInfo.Code, offsetInfo.BranchingTo)
+		operand := offsetInfo.BranchingTo
+		if offsetInfo.OperandFormat != "" {
+			operand = fmt.Sprintf(offsetInfo.OperandFormat, operand)
+		}
+		programOffset.Code = fmt.Sprintf("%s %s", offsetInfo.Code, operand)
+	}
+	if dis.Options().XrefComments {
+		setCrossReferenceComment(offsetInfo, &programOffset)
+	}
+	if dis.Options().XrefIndex && programOffset.Label != "" {
+		programOffset.References = branchFromAddresses(offsetInfo)
 	}
 
 	if offsetInfo.IsType(program.CodeOffset | program.CodeAsData | program.FunctionReference) {
@@ -178,8 +293,11 @@ func getProgramOffset(dis arch.Disasm, address uint16, offsetInfo *arch.Offset)
 		if offsetInfo.IsType(program.FunctionReference) {
 			programOffset.Code = ".word " + offsetInfo.BranchingTo
 		}
+		if renames := dis.Options().Renames; len(renames) > 0 {
+			programOffset.Code = renameSymbols(programOffset.Code, renames)
+		}
 
-		if err := setComment(dis, address, &programOffset); err != nil {
+		if err := setComment(dis, address, prgOffset, &programOffset); err != nil {
 			return program.Offset{}, err
 		}
 	} else {
@@ -189,7 +307,17 @@ func getProgramOffset(dis arch.Disasm, address uint16, offsetInfo *arch.Offset)
 	return programOffset, nil
 }
 
-func setComment(dis arch.Disasm, address uint16, programOffset *program.Offset) error {
+// renameSymbols replaces all symbol names in the code that are contained in the rename map.
+func renameSymbols(code string, renames map[string]string) string {
+	return program.ReplaceSymbols(code, func(symbol string) string {
+		if name, ok := renames[symbol]; ok {
+			return name
+		}
+		return symbol
+	})
+}
+
+func setComment(dis arch.Disasm, address uint16, prgOffset int, programOffset *program.Offset) error {
 	var comments []string
 
 	opts := dis.Options()
@@ -197,6 +325,9 @@ func setComment(dis arch.Disasm, address uint16, programOffset *program.Offset)
 		programOffset.HasAddressComment = true
 		comments = []string{fmt.Sprintf("$%04X", address)}
 	}
+	if opts.FileOffsets {
+		comments = append(comments, fmt.Sprintf("file $%05X", fileOffset(dis, prgOffset)))
+	}
 
 	if opts.HexComments {
 		hexCodeComment, err := hexCodeComment(programOffset)
@@ -213,6 +344,51 @@ func setComment(dis arch.Disasm, address uint16, programOffset *program.Offset)
 	return nil
 }
 
+// fileOffset returns the offset in the input file of the given PRG offset, which includes the
+// iNES header and trainer unless a raw binary file is disassembled.
+func fileOffset(dis arch.Disasm, prgOffset int) int {
+	if dis.Options().Binary {
+		return prgOffset
+	}
+	return prgOffset + program.INESHeaderSize + len(dis.Cart().Trainer)
+}
+
+// setCrossReferenceComment adds the addresses of all code that branches to a labeled offset
+// to the comment of the label.
+func setCrossReferenceComment(offsetInfo *arch.Offset, programOffset *program.Offset) {
+	if programOffset.Label == "" || len(offsetInfo.BranchFrom) == 0 {
+		return
+	}
+
+	addresses := branchFromAddresses(offsetInfo)
+	references := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		references = append(references, fmt.Sprintf("$%04X", address))
+	}
+
+	comment := "branched from "
+	if offsetInfo.IsType(program.CallDestination) {
+		comment = "called from "
+	}
+	comment += strings.Join(references, ", ")
+
+	programOffset.AddLabelComment(comment)
+}
+
+// branchFromAddresses returns the sorted unique addresses of the code that branches to the offset.
+func branchFromAddresses(offsetInfo *arch.Offset) []uint16 {
+	if len(offsetInfo.BranchFrom) == 0 {
+		return nil
+	}
+
+	addresses := make([]uint16, 0, len(offsetInfo.BranchFrom))
+	for _, bankRef := range offsetInfo.BranchFrom {
+		addresses = append(addresses, bankRef.Address)
+	}
+	slices.Sort(addresses)
+	return slices.Compact(addresses)
+}
+
 func hexCodeComment(offset *program.Offset) (string, error) {
 	buf := &strings.Builder{}
 
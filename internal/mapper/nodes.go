@@ -0,0 +1,58 @@
+package mapper
+
+import (
+	"slices"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// Node is a decoded instruction of the control flow graph with the addresses of all instructions
+// that can be executed after it.
+type Node struct {
+	Bank       int      `json:"bank"`
+	Address    uint16   `json:"address"`
+	Successors []uint16 `json:"successors"` // fallthrough and branch, jump or call destinations
+	Call       bool     `json:"call"`
+}
+
+// Nodes returns a node for every decoded instruction of all PRG banks, sorted by bank and address.
+// The branch destinations are collected from the branch sources that are stored for every offset.
+func (m *Mapper) Nodes(dis arch.Disasm) []Node {
+	destinations := map[*arch.Offset][]uint16{}
+	for _, bnk := range m.banks {
+		for i, offsetInfo := range bnk.offsets {
+			for _, ref := range offsetInfo.BranchFrom {
+				source := ref.Mapped.OffsetInfo(ref.Index)
+				destinations[source] = append(destinations[source], dis.CodeBaseAddress()+uint16(i))
+			}
+		}
+	}
+
+	var nodes []Node
+	for bnkIndex, bnk := range m.banks {
+		for i, offsetInfo := range bnk.offsets {
+			if !offsetInfo.IsType(program.CodeOffset) || len(offsetInfo.Data) == 0 || offsetInfo.Opcode == nil {
+				continue
+			}
+
+			instruction := offsetInfo.Opcode.Instruction()
+			address := dis.CodeBaseAddress() + uint16(i)
+			node := Node{
+				Bank:    bnkIndex,
+				Address: address,
+				Call:    instruction.IsCall(),
+			}
+
+			if instruction.ContinuesExecution() {
+				node.Successors = append(node.Successors, address+uint16(len(offsetInfo.Data)))
+			}
+			node.Successors = append(node.Successors, destinations[offsetInfo]...)
+			slices.Sort(node.Successors)
+			node.Successors = slices.Compact(node.Successors)
+
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
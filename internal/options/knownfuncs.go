@@ -0,0 +1,52 @@
+package options
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KnownFunction is a routine of a known engine or library that is recognized by the CRC32
+// checksum of its instruction bytes.
+type KnownFunction struct {
+	Name    string // label name of the function
+	Library string // name of the engine or library that contains the function
+}
+
+// ParseKnownFunctions parses a known functions database that contains one function per line in
+// the format crc32 name [library], for example 0x1a2b3c4d nmi_handler SomeLib. Empty lines and
+// lines starting with # or ; are ignored.
+func ParseKnownFunctions(reader io.Reader) (map[uint32]KnownFunction, error) {
+	functions := map[uint32]KnownFunction{}
+	scanner := bufio.NewScanner(reader)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid known function '%s' in line %d, expected format crc32 name [library]",
+				line, lineNumber)
+		}
+
+		crc, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum '%s' in line %d: %w", fields[0], lineNumber, err)
+		}
+
+		functions[uint32(crc)] = KnownFunction{
+			Name:    fields[1],
+			Library: strings.Join(fields[2:], " "),
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading known functions: %w", err)
+	}
+	return functions, nil
+}
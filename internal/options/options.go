@@ -4,6 +4,7 @@ package options
 import (
 	"io"
 	"strings"
+	"time"
 )
 
 // Program options of the disassembler.
@@ -12,36 +13,125 @@ type Program struct {
 	Batch       string
 	CodeDataLog string
 	Config      string
+	CSVFile     string
+	ExportCSV   string
 	Input       string
+	KnownFuncs  string
+	LabelsFile  string
+	Nodes       string
 	Output      string
+	RenameMap   string
 
-	AssembleTest bool
-	Binary       bool
-	Debug        bool
-	Quiet        bool
+	AssembleTest    bool
+	Binary          bool
+	Coverage        bool
+	Debug           bool
+	EmitMakefile    bool
+	OpcodeHistogram bool
+	Quiet           bool
 
 	NoHexComments bool
 	NoOffsets     bool
+
+	AnalyzeOnly bool              // only disassemble and check the quality, no .asm file is written
+	Quality     QualityThresholds // thresholds of the quality check of the analyze only mode
+
+	Timeout time.Duration // maximum duration of the disassembly of a file, 0 disables the timeout
+}
+
+// QualityThresholds defines the maximum values of the quality metrics that are accepted by the
+// quality check.
+type QualityThresholds struct {
+	MaxForcedPercent   float64 // percentage of PRG bytes that are forced to be data
+	MaxStackImbalances int
+	MaxBankCrossings   int
 }
 
+// Unreachable code output modes.
+const (
+	UnreachableCode = "code" // output unreachable code as code with a comment
+	UnreachableData = "data" // output unreachable code as data
+)
+
+// Line ending sequences of the output.
+const (
+	LineEndingsLF   = "lf"   // unix line endings
+	LineEndingsCRLF = "crlf" // windows line endings
+)
+
 // Disassembler defines options to control the disassembler.
 type Disassembler struct {
 	Assembler   string        // what assembler to use
+	CHRFile     string        // name of a binary file to write the CHR data to that gets included
 	CodeDataLog io.ReadCloser // Code/Data log file to parse
 
-	Binary                   bool
-	CodeOnly                 bool
-	HexComments              bool
-	NoUnofficialInstructions bool
-	OffsetComments           bool
-	ZeroBytes                bool
+	EntryLabel          string                   // label name of the entry point, uses the architecture default if empty
+	Exclude             []AddressRange           // address ranges that are not parsed as code
+	INESHeader          []byte                   // raw iNES header of the input file, nil for binary files
+	KnownFunctions      map[uint32]KnownFunction // known functions by the CRC32 checksum of their instruction bytes
+	LineEndings         string                   // line ending sequence of the output
+	MaxDataRun          int                      // maximum size of a labeled data block, 0 disables splitting
+	MaxLineLength       int                      // maximum length of data lines including comments, 0 disables the limit
+	MinJumpTableEntries int                      // minimum number of valid entries of a jump engine table, 0 disables the check
+	Renames             map[string]string        // maps generated label names to the names to output instead
+	SourceFile          string                   // name of the input file without its directory
+	Unreachable         string                   // output mode of unreachable code, not detected if empty
+	UnreachableComment  string                   // comment of unreachable code
+	WordTables          []WordTable              // tables of word pointers to code that get followed
+
+	AddressPrefix              bool
+	AliasDecimal               bool
+	AnnotateAddressing         bool
+	AnnotateChecksum           bool
+	AnnotateIndexed            bool
+	AnnotateMMC1               bool
+	BankChecksums              bool
+	BankCrossingWarnings       bool
+	BankScopes                 bool
+	Binary                     bool
+	BranchOffsetComments       bool
+	CHRSummary                 bool
+	CanonicalOperands          bool
+	CodeOnly                   bool
+	CollapseIdenticalFunctions bool
+	Exports                    bool
+	FileOffsets                bool
+	FunctionMetrics            bool
+	GroupDataByReference       bool
+	HexComments                bool
+	HexdumpData                bool
+	NoAutoLabels               bool
+	NoUnofficialInstructions   bool
+	OffsetComments             bool
+	Provenance                 bool
+	SeedFromCDLCalls           bool
+	SplitHeader                bool
+	StackCheck                 bool
+	SymbolicOffsets            bool
+	TableOfContents            bool
+	XrefComments               bool
+	XrefIndex                  bool
+	ZeroBytes                  bool
 }
 
 // NewDisassembler returns a new options instance with default options.
 func NewDisassembler(assemblerName string) Disassembler {
 	return Disassembler{
-		Assembler:      strings.ToLower(assemblerName),
-		HexComments:    true,
-		OffsetComments: true,
+		Assembler:          strings.ToLower(assemblerName),
+		HexComments:        true,
+		LineEndings:        LineEndingsLF,
+		OffsetComments:     true,
+		UnreachableComment: "unreachable code",
+	}
+}
+
+// Excluded returns whether the address is inside one of the address ranges that are excluded
+// from the code analysis.
+func (d Disassembler) Excluded(address uint16) bool {
+	for _, r := range d.Exclude {
+		if r.Contains(address) {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,52 @@
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AddressRange defines an inclusive range of addresses.
+type AddressRange struct {
+	Start uint16
+	End   uint16
+}
+
+// Contains returns whether the address is inside the range.
+func (r AddressRange) Contains(address uint16) bool {
+	return address >= r.Start && address <= r.End
+}
+
+// ParseAddressRange parses an address range in the format start-end, for example 0x9000-0x9FFF.
+func ParseAddressRange(s string) (AddressRange, error) {
+	startValue, endValue, ok := strings.Cut(s, "-")
+	if !ok {
+		return AddressRange{}, fmt.Errorf("address range '%s' is missing the '-' separator", s)
+	}
+
+	start, err := parseAddress(startValue)
+	if err != nil {
+		return AddressRange{}, err
+	}
+	end, err := parseAddress(endValue)
+	if err != nil {
+		return AddressRange{}, err
+	}
+	if start > end {
+		return AddressRange{}, fmt.Errorf("address range '%s' starts after its end", s)
+	}
+
+	return AddressRange{Start: start, End: end}, nil
+}
+
+// parseAddress parses a hexadecimal address with an optional 0x or $ prefix.
+func parseAddress(s string) (uint16, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "0x"), "$")
+
+	i, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("parsing address '%s': %w", s, err)
+	}
+	return uint16(i), nil
+}
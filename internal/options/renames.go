@@ -0,0 +1,35 @@
+package options
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseRenameMap parses a rename map that contains one rename per line in the format old=new,
+// for example _label_8003=init_ppu. Empty lines and lines starting with # or ; are ignored.
+func ParseRenameMap(reader io.Reader) (map[string]string, error) {
+	renames := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		oldName, newName, ok := strings.Cut(line, "=")
+		oldName = strings.TrimSpace(oldName)
+		newName = strings.TrimSpace(newName)
+		if !ok || oldName == "" || newName == "" {
+			return nil, fmt.Errorf("invalid rename '%s' in line %d, expected format old=new", line, lineNumber)
+		}
+		renames[oldName] = newName
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading rename map: %w", err)
+	}
+	return renames, nil
+}
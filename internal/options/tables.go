@@ -0,0 +1,36 @@
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WordTable defines a table of little endian word pointers to code.
+type WordTable struct {
+	Address uint16
+	Entries int
+}
+
+// ParseWordTable parses a word table in the format address:entries, for example 0x8015:8.
+func ParseWordTable(s string) (WordTable, error) {
+	addressValue, entriesValue, ok := strings.Cut(s, ":")
+	if !ok {
+		return WordTable{}, fmt.Errorf("word table '%s' is missing the ':' separator", s)
+	}
+
+	address, err := parseAddress(addressValue)
+	if err != nil {
+		return WordTable{}, err
+	}
+
+	entries, err := strconv.Atoi(strings.TrimSpace(entriesValue))
+	if err != nil {
+		return WordTable{}, fmt.Errorf("parsing word table entries '%s': %w", entriesValue, err)
+	}
+	if entries <= 0 {
+		return WordTable{}, fmt.Errorf("word table '%s' needs at least one entry", s)
+	}
+
+	return WordTable{Address: address, Entries: entries}, nil
+}
@@ -1,17 +1,20 @@
 package disasm
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/retroenv/nesgodisasm/internal/arch"
 	"github.com/retroenv/nesgodisasm/internal/program"
 )
 
+const bankCrossingComment = "branch/jump crosses bank window"
+
 // followExecutionFlow parses opcodes and follows the execution flow to parse all code.
 // nolint: funlen
-func (dis *Disasm) followExecutionFlow() error {
+func (dis *Disasm) followExecutionFlow(ctx context.Context) error {
 	for {
-		address, err := dis.addressToDisassemble()
+		address, err := dis.addressToDisassemble(ctx)
 		if err != nil {
 			return err
 		}
@@ -46,16 +49,19 @@ func (dis *Disasm) followExecutionFlow() error {
 	return nil
 }
 
-// in case the current instruction overlaps with an already existing instruction,
-// cut the current one short.
+// in case the current instruction overlaps with an already existing instruction or an excluded
+// address range, cut the current one short.
 func (dis *Disasm) checkInstructionOverlap(address uint16, offsetInfo *arch.Offset) {
 	for i := 1; i < len(offsetInfo.Data) && int(address)+i < int(dis.arch.LastCodeAddress()); i++ {
-		offsetInfoFollowing := dis.mapper.OffsetInfo(address + uint16(i))
-		if !offsetInfoFollowing.IsType(program.CodeOffset) {
-			continue
+		if !dis.options.Excluded(address + uint16(i)) {
+			offsetInfoFollowing := dis.mapper.OffsetInfo(address + uint16(i))
+			if !offsetInfoFollowing.IsType(program.CodeOffset) {
+				continue
+			}
+
+			offsetInfoFollowing.Comment = "branch into instruction detected"
 		}
 
-		offsetInfoFollowing.Comment = "branch into instruction detected"
 		offsetInfo.Comment = offsetInfo.Code
 		offsetInfo.Data = offsetInfo.Data[:i]
 		offsetInfo.Code = ""
@@ -67,9 +73,13 @@ func (dis *Disasm) checkInstructionOverlap(address uint16, offsetInfo *arch.Offs
 
 // addressToDisassemble returns the next address to disassemble, if there are no more addresses to parse,
 // 0 will be returned. Return address from function addresses have the lowest priority, to be able to
-// handle jump table functions correctly.
-func (dis *Disasm) addressToDisassemble() (uint16, error) {
+// handle jump table functions correctly. The context error is returned if the context is cancelled.
+func (dis *Disasm) addressToDisassemble(ctx context.Context) (uint16, error) {
 	for {
+		if err := ctx.Err(); err != nil {
+			return 0, fmt.Errorf("following execution flow: %w", err)
+		}
+
 		if len(dis.offsetsToParse) > 0 {
 			address := dis.offsetsToParse[0]
 			dis.offsetsToParse = dis.offsetsToParse[1:]
@@ -94,12 +104,50 @@ func (dis *Disasm) addressToDisassemble() (uint16, error) {
 		if err != nil {
 			return 0, fmt.Errorf("scanning for new jump engine entry: %w", err)
 		}
-		if !isEntry {
+		if !isEntry && !dis.addReachableAddressesToParse() {
 			return 0, nil
 		}
 	}
 }
 
+// unreachableAddress is an address that is only reachable if the instruction at the from address
+// is a branch destination.
+type unreachableAddress struct {
+	address            uint16
+	context            uint16
+	from               uint16
+	currentInstruction arch.Instruction
+}
+
+// AddUnreachableAddressToParse adds an address that is only reachable if the instruction at the from
+// address is a branch destination. This is checked after all other addresses have been parsed, once
+// all branch destinations are known.
+func (dis *Disasm) AddUnreachableAddressToParse(address, context, from uint16, currentInstruction arch.Instruction) {
+	dis.unreachableToParse = append(dis.unreachableToParse, unreachableAddress{
+		address:            address,
+		context:            context,
+		from:               from,
+		currentInstruction: currentInstruction,
+	})
+}
+
+// addReachableAddressesToParse adds all unreachable addresses to parse whose instruction at the
+// from address has become a branch destination and returns whether any address was added.
+func (dis *Disasm) addReachableAddressesToParse() bool {
+	var added bool
+	unreachable := dis.unreachableToParse[:0]
+	for _, info := range dis.unreachableToParse {
+		if _, ok := dis.branchDestinations[info.from]; !ok {
+			unreachable = append(unreachable, info)
+			continue
+		}
+		dis.AddAddressToParse(info.address, info.context, info.from, info.currentInstruction, false)
+		added = true
+	}
+	dis.unreachableToParse = unreachable
+	return added
+}
+
 // AddAddressToParse adds an address to the list to be processed if the address has not been processed yet.
 func (dis *Disasm) AddAddressToParse(address, context, from uint16,
 	currentInstruction arch.Instruction, isABranchDestination bool) {
@@ -129,10 +177,20 @@ func (dis *Disasm) AddAddressToParse(address, context, from uint16,
 			}
 			bankRef.ID = bankRef.Mapped.ID()
 			offsetInfo.BranchFrom = append(offsetInfo.BranchFrom, bankRef)
+
+			if dis.options.BankCrossingWarnings {
+				dis.checkBankCrossing(address, bankRef, currentInstruction)
+			}
 		}
 		dis.branchDestinations[address] = struct{}{}
 	}
 
+	// never parse code in address ranges that are excluded from the code analysis,
+	// the references to it are kept to label the data.
+	if dis.options.Excluded(address) {
+		return
+	}
+
 	if _, ok := dis.offsetsToParseAdded[address]; ok {
 		return
 	}
@@ -153,3 +211,19 @@ func (dis *Disasm) AddAddressToParse(address, context, from uint16,
 func (dis *Disasm) DeleteFunctionReturnToParse(address uint16) {
 	delete(dis.functionReturnsToParseAdded, address)
 }
+
+// checkBankCrossing annotates a branch or jump whose destination is mapped from a different bank
+// than the instruction itself. Code can not continue in another bank without a bank switch, this
+// usually indicates code that was detected wrongly.
+func (dis *Disasm) checkBankCrossing(address uint16, from arch.BankReference, currentInstruction arch.Instruction) {
+	if currentInstruction == nil || currentInstruction.IsCall() {
+		return
+	}
+	if dis.mapper.GetMappedBank(address).ID() == from.ID {
+		return
+	}
+
+	offsetInfo := from.Mapped.OffsetInfo(from.Index)
+	offsetInfo.AddComment(bankCrossingComment)
+	dis.AddWarning(arch.BankCrossingWarning)
+}
@@ -14,3 +14,21 @@ func (chr CHR) GetLastNonZeroByte() int {
 	}
 	return 0
 }
+
+// TileSize is the size in bytes of a CHR tile.
+const TileSize = 16
+
+// TileCount returns the number of complete tiles in CHR.
+func (chr CHR) TileCount() int {
+	return len(chr) / TileSize
+}
+
+// BlankTile returns whether all bytes of the tile with the given index are zero.
+func (chr CHR) BlankTile(index int) bool {
+	for _, b := range chr[index*TileSize : (index+1)*TileSize] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
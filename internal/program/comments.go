@@ -0,0 +1,25 @@
+package program
+
+// commentSeparator separates multiple comments of a line.
+const commentSeparator = "  "
+
+// JoinComments joins two comments, keeping the order. Empty comments are skipped.
+func JoinComments(first, second string) string {
+	if first == "" {
+		return second
+	}
+	if second == "" {
+		return first
+	}
+	return first + commentSeparator + second
+}
+
+// AddComment adds a comment to the offset, keeping an already existing comment.
+func (o *Offset) AddComment(comment string) {
+	o.Comment = JoinComments(o.Comment, comment)
+}
+
+// AddLabelComment adds a comment to the label of the offset, keeping an already existing comment.
+func (o *Offset) AddLabelComment(comment string) {
+	o.LabelComment = JoinComments(o.LabelComment, comment)
+}
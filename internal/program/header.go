@@ -0,0 +1,67 @@
+package program
+
+import (
+	"github.com/retroenv/retrogolib/arch/nes/cartridge"
+)
+
+// INESHeaderSize is the size of the iNES file header in bytes.
+const INESHeaderSize = 16
+
+const (
+	nes2IdentifierMask = 0b0000_1100 // bits of control byte 2 that identify the NES 2.0 format
+	nes2Identifier     = 0b0000_1000
+	nes2ConsoleMask    = 0b0000_0011 // console type bits of control byte 2
+	nes2MapperIndex    = 8           // header index of the mapper MSB and submapper byte
+	nes2ROMSizeIndex   = 9           // header index of the PRG-ROM and CHR-ROM size MSB byte
+	nes2RAMIndex       = 10          // header index of the PRG-RAM size shift count byte
+	nes2TimingIndex    = 12          // header index of the CPU/PPU timing byte
+	nes2VsTypeIndex    = 13          // header index of the Vs. System type byte
+	nes2MiscROMsIndex  = 14          // header index of the miscellaneous ROMs byte
+	nes2ExpansionIndex = 15          // header index of the default expansion device byte
+	nes2TimingMask     = 0b0000_0011
+)
+
+// consoleTypeNames contains the names of the NES 2.0 console types.
+var consoleTypeNames = [...]string{"NES/Famicom", "Vs. System", "Playchoice 10", "Extended console type"}
+
+// timingNames contains the names of the NES 2.0 CPU/PPU timing modes.
+var timingNames = [...]string{"NTSC", "PAL", "Multi-region", "Dendy"}
+
+// SetNES2Header sets the NES 2.0 header fields of the program if the passed raw iNES header is in
+// the NES 2.0 format. Fields that are not interpreted by the disassembler are kept as raw bytes to
+// recreate the exact header.
+func (p *Program) SetNES2Header(header []byte) {
+	if len(header) < INESHeaderSize || header[7]&nes2IdentifierMask != nes2Identifier {
+		return
+	}
+
+	p.NES2 = true
+	p.ConsoleType = header[7] & nes2ConsoleMask
+	p.MapperMSB = header[nes2MapperIndex]
+	p.ROMSizeMSB = header[nes2ROMSizeIndex]
+	copy(p.RAMShifts[:], header[nes2RAMIndex:])
+	p.Timing = header[nes2TimingIndex] & nes2TimingMask
+	p.VsType = header[nes2VsTypeIndex]
+	p.MiscROMs = header[nes2MiscROMsIndex]
+	p.ExpansionDevice = header[nes2ExpansionIndex]
+}
+
+// ControlBytes returns the control bytes of the iNES header, including the NES 2.0 identifier
+// and console type for programs with a NES 2.0 header.
+func (p Program) ControlBytes() (byte, byte) {
+	control1, control2 := cartridge.ControlBytes(p.Battery, byte(p.Mirror), p.Mapper, len(p.Trainer) > 0)
+	if p.NES2 {
+		control2 |= nes2Identifier | p.ConsoleType
+	}
+	return control1, control2
+}
+
+// ConsoleTypeName returns the name of the NES 2.0 console type.
+func (p Program) ConsoleTypeName() string {
+	return consoleTypeNames[p.ConsoleType&nes2ConsoleMask]
+}
+
+// TimingName returns the name of the NES 2.0 CPU/PPU timing mode.
+func (p Program) TimingName() string {
+	return timingNames[p.Timing&nes2TimingMask]
+}
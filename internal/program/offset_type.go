@@ -1,7 +1,7 @@
 package program
 
 // OffsetType defines the type of a program offset.
-type OffsetType uint8
+type OffsetType uint16
 
 // addressing modes.
 const (
@@ -13,6 +13,7 @@ const (
 	JumpEngine
 	JumpTable
 	FunctionReference // reference to a function
+	DataBlockEnd      // last byte of a data block with a detected size
 )
 
 // IsType returns whether the offset is of given type.
@@ -7,21 +7,24 @@ import (
 // NewPRGBank creates a new PRG bank.
 func NewPRGBank(size int) *PRGBank {
 	return &PRGBank{
-		Offsets:   make([]Offset, size),
-		Constants: map[string]uint16{},
-		Variables: map[string]uint16{},
+		Offsets:        make([]Offset, size),
+		Constants:      map[string]uint16{},
+		ConstantGroups: map[string]string{},
+		Variables:      map[string]uint16{},
 	}
 }
 
 // PRGBank defines a PRG bank.
 type PRGBank struct {
-	Name string
+	Name     string
+	Checksum uint32 // CRC32 checksum of the bank bytes
 
 	Offsets []Offset
 	Vectors [3]uint16
 
-	Constants map[string]uint16
-	Variables map[string]uint16
+	Constants      map[string]uint16
+	ConstantGroups map[string]string // maps a constant name to its group name
+	Variables      map[string]uint16
 }
 
 // GetLastNonZeroByte searches for the last byte in PRG that is not zero.
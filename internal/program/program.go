@@ -28,6 +28,7 @@ type Offset struct {
 	Code         string // asm output of this instruction
 	Comment      string
 	LabelComment string
+	References   []uint16 // sorted addresses of the code that branches to the label
 }
 
 // Handlers defines the handlers that the NES can jump to.
@@ -61,23 +62,37 @@ type Program struct {
 	Mapper      byte
 	VideoFormat byte
 
+	// NES 2.0 header fields, only set if the header of the input file is in the NES 2.0 format
+	NES2            bool
+	ConsoleType     byte    // 0 NES/Famicom, 1 Vs. System, 2 Playchoice 10, 3 extended console type
+	MapperMSB       byte    // mapper number MSB and submapper number
+	ROMSizeMSB      byte    // PRG-ROM and CHR-ROM size MSB
+	RAMShifts       [2]byte // PRG-RAM and CHR-RAM size shift counts
+	Timing          byte    // CPU/PPU timing, 0 NTSC, 1 PAL, 2 multi-region, 3 Dendy
+	VsType          byte    // Vs. System PPU and hardware type
+	MiscROMs        byte    // number of miscellaneous ROMs
+	ExpansionDevice byte    // default expansion device
+
 	// keep constants and variables in the banks and global in the app to let the chosen assembler decide
 	// how to output them
-	Constants map[string]uint16
-	Variables map[string]uint16
+	Constants      map[string]uint16
+	ConstantGroups map[string]string // maps a constant name to its group name
+	Variables      map[string]uint16
 }
 
 // New creates a new program initialize with a program code size.
 func New(cart *cartridge.Cartridge) *Program {
 	return &Program{
-		CHR:       cart.CHR,
-		RAM:       cart.RAM,
-		Battery:   cart.Battery,
-		Mapper:    cart.Mapper,
-		Mirror:    cart.Mirror,
-		Trainer:   cart.Trainer,
-		Constants: map[string]uint16{},
-		Variables: map[string]uint16{},
+		CHR:            cart.CHR,
+		RAM:            cart.RAM,
+		Battery:        cart.Battery,
+		Mapper:         cart.Mapper,
+		Mirror:         cart.Mirror,
+		Trainer:        cart.Trainer,
+		VideoFormat:    cart.VideoFormat,
+		Constants:      map[string]uint16{},
+		ConstantGroups: map[string]string{},
+		Variables:      map[string]uint16{},
 	}
 }
 
@@ -0,0 +1,13 @@
+package program
+
+import "regexp"
+
+// symbolExpression matches all words of a code line that can be a symbol name, numbers are matched
+// including their prefix to not treat hex digits as symbol names.
+var symbolExpression = regexp.MustCompile(`[$%]?[A-Za-z0-9_]+`)
+
+// ReplaceSymbols replaces all symbol names of the code line by the result of the replace function,
+// which returns the symbol unchanged to keep it.
+func ReplaceSymbols(code string, replace func(symbol string) string) string {
+	return symbolExpression.ReplaceAllStringFunc(code, replace)
+}
@@ -0,0 +1,80 @@
+package disasm
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/arch"
+	"github.com/retroenv/nesgodisasm/internal/options"
+)
+
+// Exit codes of a failed quality check, every exceeded threshold has a distinct code.
+const (
+	ExitCodeForcedBytes     = 3
+	ExitCodeStackImbalances = 4
+	ExitCodeBankCrossings   = 5
+)
+
+// Quality contains metrics of suspicious disassembly results.
+type Quality struct {
+	ForcedPercent   float64 // percentage of PRG bytes that are forced to be data by excluded address ranges or ambiguous instructions
+	StackImbalances int     // number of instructions with an unbalanced stack
+	BankCrossings   int     // number of branches and jumps into a different bank window
+}
+
+// QualityError is returned by a quality check if a metric exceeds its threshold.
+type QualityError struct {
+	ExitCode int // exit code of the process that identifies the exceeded threshold
+	Message  string
+}
+
+// Error returns the description of the exceeded threshold.
+func (e *QualityError) Error() string {
+	return e.Message
+}
+
+// AddWarning counts a suspicious disassembly result for the quality metrics.
+func (dis *Disasm) AddWarning(warning arch.Warning) {
+	dis.warnings[warning]++
+}
+
+// Quality returns the quality metrics of the disassembly,
+// it has to be called after the cartridge has been disassembled.
+func (dis *Disasm) Quality() Quality {
+	coverage := dis.mapper.Coverage(dis)
+	forced := coverage.Excluded + coverage.CodeAsData
+
+	return Quality{
+		ForcedPercent:   float64(forced) * 100 / float64(coverage.Total()),
+		StackImbalances: dis.warnings[arch.StackImbalanceWarning],
+		BankCrossings:   dis.warnings[arch.BankCrossingWarning],
+	}
+}
+
+// Check returns a quality error for the first metric that exceeds its threshold.
+func (q Quality) Check(thresholds options.QualityThresholds) error {
+	switch {
+	case q.ForcedPercent > thresholds.MaxForcedPercent:
+		return &QualityError{
+			ExitCode: ExitCodeForcedBytes,
+			Message: fmt.Sprintf("%.1f%% of PRG bytes are forced to be data, maximum is %.1f%%",
+				q.ForcedPercent, thresholds.MaxForcedPercent),
+		}
+
+	case q.StackImbalances > thresholds.MaxStackImbalances:
+		return &QualityError{
+			ExitCode: ExitCodeStackImbalances,
+			Message: fmt.Sprintf("%d stack imbalances found, maximum is %d",
+				q.StackImbalances, thresholds.MaxStackImbalances),
+		}
+
+	case q.BankCrossings > thresholds.MaxBankCrossings:
+		return &QualityError{
+			ExitCode: ExitCodeBankCrossings,
+			Message: fmt.Sprintf("%d branches or jumps cross a bank window, maximum is %d",
+				q.BankCrossings, thresholds.MaxBankCrossings),
+		}
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+// Package symbols provides writing of the symbols of a disassembled program.
+package symbols
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// Write writes all labels and variables of the program as address to name map to the writer.
+// Every line contains one symbol in the format "$8000 Reset", the labels are written per bank
+// in address order, followed by the variables.
+func Write(app *program.Program, writer io.Writer) error {
+	for _, bank := range app.PRG {
+		for _, offset := range bank.Offsets {
+			if offset.Label == "" {
+				continue
+			}
+			if _, err := fmt.Fprintf(writer, "$%04X %s\n", offset.Address, offset.Label); err != nil {
+				return fmt.Errorf("writing label: %w", err)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(app.Variables))
+	for name := range app.Variables {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if app.Variables[a] != app.Variables[b] {
+			return int(app.Variables[a]) - int(app.Variables[b])
+		}
+		return strings.Compare(a, b)
+	})
+
+	for _, name := range names {
+		if _, err := fmt.Fprintf(writer, "$%04X %s\n", app.Variables[name], name); err != nil {
+			return fmt.Errorf("writing variable: %w", err)
+		}
+	}
+	return nil
+}
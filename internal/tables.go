@@ -0,0 +1,53 @@
+package disasm
+
+import (
+	"fmt"
+
+	"github.com/retroenv/nesgodisasm/internal/options"
+	"github.com/retroenv/nesgodisasm/internal/program"
+)
+
+// applyWordTables marks all entries of the word tables that are declared in the options as
+// function references and adds their destinations to the addresses to parse.
+func (dis *Disasm) applyWordTables() error {
+	for _, table := range dis.options.WordTables {
+		if err := dis.applyWordTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyWordTable marks all entries of the word table as function references and adds their
+// destinations to the addresses to parse.
+func (dis *Disasm) applyWordTable(table options.WordTable) error {
+	end := uint32(table.Address) + uint32(2*table.Entries) - 1
+	if table.Address < dis.codeBaseAddress || end >= uint32(dis.arch.LastCodeAddress()) {
+		return fmt.Errorf("word table at $%04X with %d entries is outside of the code", table.Address, table.Entries)
+	}
+
+	for i := range table.Entries {
+		address := table.Address + uint16(2*i)
+		destination, err := dis.ReadMemoryWord(address)
+		if err != nil {
+			return fmt.Errorf("reading word table entry: %w", err)
+		}
+		if destination < dis.codeBaseAddress || destination >= dis.arch.LastCodeAddress() {
+			return fmt.Errorf("word table entry at $%04X points to $%04X outside of the code", address, destination)
+		}
+
+		offsetInfo1 := dis.mapper.OffsetInfo(address)
+		offsetInfo2 := dis.mapper.OffsetInfo(address + 1)
+		if i == 0 {
+			offsetInfo1.SetType(program.JumpTable)
+		}
+		offsetInfo1.SetType(program.FunctionReference)
+		offsetInfo2.SetType(program.FunctionReference)
+
+		offsetInfo1.Data = []byte{byte(destination), byte(destination >> 8)}
+		offsetInfo2.Data = nil
+
+		dis.AddAddressToParse(destination, destination, address, nil, true)
+	}
+	return nil
+}
@@ -14,16 +14,25 @@ const (
 	dataNaming            = "_data_%04x"
 	dataNamingIndexed     = "_data_%04x_indexed"
 	jumpTableNaming       = "_jump_table_%04x"
+	sramNaming            = "sram_%04x"
 	variableNaming        = "_var_%04x"
 	variableNamingIndexed = "_var_%04x_indexed"
 )
 
+const (
+	sramStart = 0x6000
+	sramEnd   = 0x7fff
+
+	batteryComment = "battery-backed save RAM"
+)
+
 // Vars manages variables in the disassembled program.
 type Vars struct {
 	arch arch.Architecture
 
 	banks []*bank
 
+	buffers       []buffer
 	variables     map[uint16]*variable
 	usedVariables map[uint16]struct{}
 }
@@ -43,6 +52,12 @@ type variable struct {
 	usageAt      []arch.BankReference // list of all indexes that use this offset
 }
 
+type buffer struct {
+	address uint16
+	size    int
+	name    string
+}
+
 // New creates a new variables manager.
 func New(arch arch.Architecture) *Vars {
 	return &Vars{
@@ -99,6 +114,15 @@ func (v *Vars) AddReference(dis arch.Disasm, addressReference,
 	}
 }
 
+// AddBuffer adds a named buffer, all accesses into the buffer get named as offset from the buffer start.
+func (v *Vars) AddBuffer(address uint16, size int, name string) {
+	v.buffers = append(v.buffers, buffer{
+		address: address,
+		size:    size,
+		name:    name,
+	})
+}
+
 // Process processes all variables and updates the instructions that use them
 // with a generated alias name.
 func (v *Vars) Process(dis arch.Disasm) error {
@@ -111,10 +135,16 @@ func (v *Vars) Process(dis arch.Disasm) error {
 	})
 
 	for _, varInfo := range variables {
-		if len(varInfo.usageAt) == 1 && !varInfo.indexedUsage && varInfo.address < nes.CodeBaseAddress {
-			if !varInfo.reads || !varInfo.writes {
-				continue // ignore only once usages or ones that are not read and write
+		if buf, ok := v.bufferAt(varInfo.address); ok {
+			if err := v.processBufferUsage(buf, varInfo); err != nil {
+				return err
 			}
+			continue
+		}
+
+		batteryBacked := isSRAM(varInfo.address) && dis.Cart().Battery != 0
+		if ignoreVariable(varInfo, batteryBacked) {
+			continue
 		}
 
 		var dataOffsetInfo *arch.Offset
@@ -123,6 +153,9 @@ func (v *Vars) Process(dis arch.Disasm) error {
 		if varInfo.address >= codeBaseAddress {
 			// if the referenced address is inside the code, a label will be created for it
 			dataOffsetInfo, varInfo.address, addressAdjustment = v.getOpcodeStart(dis, varInfo.address)
+			if addressAdjustment > 0 && dis.Options().GroupDataByReference {
+				dataOffsetInfo, varInfo.address, addressAdjustment = referencedDataOffset(dis, dataOffsetInfo, varInfo.address, addressAdjustment)
+			}
 		} else {
 			// if the address is outside the code bank, a variable will be created
 			v.usedVariables[varInfo.address] = struct{}{}
@@ -141,6 +174,64 @@ func (v *Vars) Process(dis arch.Disasm) error {
 			if err := v.arch.ProcessVariableUsage(offsetInfo, reference); err != nil {
 				return fmt.Errorf("processing variable usage: %w", err)
 			}
+			if batteryBacked {
+				offsetInfo.AddComment(batteryComment)
+			}
+		}
+	}
+	return nil
+}
+
+// ignoreVariable returns whether no name should be created for the variable. Variables that are used only
+// once or that are not read and written are ignored, except all accesses to the save RAM of battery backed
+// cartridges.
+func ignoreVariable(varInfo *variable, batteryBacked bool) bool {
+	if len(varInfo.usageAt) != 1 || varInfo.indexedUsage || varInfo.address >= nes.CodeBaseAddress || batteryBacked {
+		return false
+	}
+	return !varInfo.reads || !varInfo.writes
+}
+
+// isSRAM returns whether the address is inside the PRG-RAM that is used as save RAM by battery backed cartridges.
+func isSRAM(address uint16) bool {
+	return address >= sramStart && address <= sramEnd
+}
+
+// bufferAt returns the buffer that contains the given address.
+func (v *Vars) bufferAt(address uint16) (buffer, bool) {
+	for _, buf := range v.buffers {
+		if address >= buf.address && int(address) < int(buf.address)+buf.size {
+			return buf, true
+		}
+	}
+	return buffer{}, false
+}
+
+// processBufferUsage names the usages of a variable inside of a buffer as offset from the buffer start.
+// Only the buffer start gets output as variable.
+func (v *Vars) processBufferUsage(buf buffer, varInfo *variable) error {
+	base := v.variables[buf.address]
+	if base == nil {
+		base = &variable{
+			address: buf.address,
+		}
+		v.variables[buf.address] = base
+	}
+	base.name = buf.name
+	v.usedVariables[buf.address] = struct{}{}
+
+	reference := buf.name
+	if offset := varInfo.address - buf.address; offset > 0 {
+		reference = fmt.Sprintf("%s+%d", reference, offset)
+	}
+	varInfo.name = reference
+
+	for _, bankRef := range varInfo.usageAt {
+		v.AddUsage(bankRef.ID, base)
+
+		offsetInfo := bankRef.Mapped.OffsetInfo(bankRef.Index)
+		if err := v.arch.ProcessVariableUsage(offsetInfo, reference); err != nil {
+			return fmt.Errorf("processing variable usage: %w", err)
 		}
 	}
 	return nil
@@ -197,6 +288,8 @@ func (v *Vars) dataName(offsetInfo *arch.Offset, indexedUsage bool, address, add
 		switch {
 		case jumpTable:
 			name = fmt.Sprintf(jumpTableNaming, address)
+		case !prgAccess && isSRAM(address):
+			name = fmt.Sprintf(sramNaming, address)
 		case prgAccess && indexedUsage:
 			name = fmt.Sprintf(dataNamingIndexed, address)
 		case prgAccess && !indexedUsage:
@@ -218,6 +311,22 @@ func (v *Vars) dataName(offsetInfo *arch.Offset, indexedUsage bool, address, add
 	return name, reference
 }
 
+// referencedDataOffset returns the offset of a referenced address inside of combined data bytes, which
+// gets its own label instead of being referenced with an adjuster like +1. The writer splits the
+// combined data bytes at the label. Instructions are not split.
+func referencedDataOffset(dis arch.Disasm, offsetInfo *arch.Offset, address, addressAdjustment uint16) (*arch.Offset, uint16, uint16) {
+	if !offsetInfo.IsType(program.DataOffset) || offsetInfo.IsType(program.CodeOffset) {
+		return offsetInfo, address, addressAdjustment
+	}
+
+	referenced := address + addressAdjustment
+	referencedInfo := dis.Mapper().OffsetInfo(referenced)
+	if !referencedInfo.IsType(program.DataOffset) {
+		return offsetInfo, address, addressAdjustment
+	}
+	return referencedInfo, referenced, 0
+}
+
 // SetBankVariables sets the used variables in the bank for outputting.
 func (v *Vars) SetBankVariables(bankID int, prgBank *program.PRGBank) {
 	bank := v.banks[bankID]
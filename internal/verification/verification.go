@@ -18,6 +18,9 @@ import (
 	"github.com/retroenv/retrogolib/log"
 )
 
+// nes2ControlIndex is the header index of the control byte that identifies the NES 2.0 format.
+const nes2ControlIndex = 7
+
 // VerifyOutput verifies that the output file recreates the exact input file.
 func VerifyOutput(logger *log.Logger, options options.Program,
 	cart *cartridge.Cartridge, app *program.Program) error {
@@ -167,5 +170,24 @@ func compareCartridgeDetails(logger *log.Logger, input, output []byte) error {
 	if cart1.Battery != cart2.Battery {
 		return fmt.Errorf("battery mismatch, expected %d but got %d", cart1.Battery, cart2.Battery)
 	}
+	return compareNES2Header(input, output)
+}
+
+// compareNES2Header compares the header fields of a NES 2.0 input file with the output file.
+// The header bytes following the control bytes of iNES files are not compared as they are
+// often filled with garbage.
+func compareNES2Header(input, output []byte) error {
+	var app program.Program
+	app.SetNES2Header(input)
+	if !app.NES2 {
+		return nil
+	}
+
+	for i := nes2ControlIndex; i < program.INESHeaderSize; i++ {
+		if input[i] != output[i] {
+			return fmt.Errorf("NES 2.0 header mismatch at offset %d, expected 0x%02X but got 0x%02X",
+				i, input[i], output[i])
+		}
+	}
 	return nil
 }
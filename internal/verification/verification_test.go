@@ -0,0 +1,89 @@
+package verification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	disasm "github.com/retroenv/nesgodisasm/internal"
+	"github.com/retroenv/nesgodisasm/internal/arch/m6502"
+	"github.com/retroenv/nesgodisasm/internal/assembler"
+	"github.com/retroenv/nesgodisasm/internal/assembler/asm6"
+	"github.com/retroenv/nesgodisasm/internal/options"
+	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/retrogolib/arch/nes/cartridge"
+	"github.com/retroenv/retrogolib/assert"
+	"github.com/retroenv/retrogolib/log"
+)
+
+// nes2PALHeader is the NES 2.0 header of a Vs. System ROM with PAL timing and a set
+// submapper, Vs. System type, miscellaneous ROM count and default expansion device.
+var nes2PALHeader = []byte{'N', 'E', 'S', 0x1a, 0x01, 0x01, 0x00, 0x09, 0x10, 0x00, 0x07, 0x00, 0x01, 0x02, 0x01, 0x01}
+
+// nes2PALROM returns a ROM file with the NES 2.0 PAL header that only contains a reset handler.
+func nes2PALROM() []byte {
+	prg := make([]byte, 0x4000)
+	prg[0] = 0x40 // rti
+	prg[0x3ffc] = 0x00
+	prg[0x3ffd] = 0xc0
+
+	rom := append([]byte{}, nes2PALHeader...)
+	rom = append(rom, prg...)
+	return append(rom, make([]byte, 0x2000)...)
+}
+
+func TestCompareCartridgeDetailsNES2Header(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	input := nes2PALROM()
+	assert.NoError(t, compareCartridgeDetails(logger, input, input))
+
+	for _, index := range []int{8, 12, 13, 14, 15} {
+		output := bytes.Clone(input)
+		output[index] = 0
+		expected := fmt.Sprintf("NES 2.0 header mismatch at offset %d, expected 0x%02X but got 0x00", index, input[index])
+		assert.Error(t, compareCartridgeDetails(logger, input, output), expected)
+	}
+
+	// the unused header bytes of iNES files are not compared
+	input[7] = 0x01
+	output := bytes.Clone(input)
+	output[12] = 0
+	assert.NoError(t, compareCartridgeDetails(logger, input, output))
+}
+
+func TestVerifyOutputNES2Header(t *testing.T) {
+	if _, err := exec.LookPath("asm6f"); err != nil {
+		t.Skip("asm6f is not installed")
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "pal.nes")
+	assert.NoError(t, os.WriteFile(input, nes2PALROM(), 0o644))
+
+	cart, err := cartridge.LoadFile(bytes.NewReader(nes2PALROM()))
+	assert.NoError(t, err)
+
+	opts := options.NewDisassembler(assembler.Asm6)
+	opts.INESHeader = nes2PALHeader[:program.INESHeaderSize]
+	ar := m6502.New(asm6.Syntax)
+	dis, err := disasm.New(ar, log.NewTestLogger(t), cart, opts, asm6.New)
+	assert.NoError(t, err)
+
+	output := filepath.Join(dir, "pal.asm")
+	file, err := os.Create(output)
+	assert.NoError(t, err)
+	app, err := dis.Process(context.Background(), file, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	programOptions := options.Program{
+		Assembler: assembler.Asm6,
+		Input:     input,
+		Output:    output,
+	}
+	assert.NoError(t, VerifyOutput(log.NewTestLogger(t), programOptions, cart, app))
+}
@@ -0,0 +1,56 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// lineEndingWriter translates the line feeds of all written data to a different line ending sequence.
+type lineEndingWriter struct {
+	writer     io.Writer
+	lineEnding []byte
+}
+
+// lineEndingWriteCloser is a line ending translating writer that closes the wrapped writer.
+type lineEndingWriteCloser struct {
+	lineEndingWriter
+	closer io.Closer
+}
+
+// NewLineEndingWriter returns a writer that replaces every line feed by the given line ending
+// sequence before writing the data to the wrapped writer.
+func NewLineEndingWriter(writer io.Writer, lineEnding string) io.Writer {
+	return lineEndingWriter{
+		writer:     writer,
+		lineEnding: []byte(lineEnding),
+	}
+}
+
+// NewLineEndingWriteCloser returns a write closer that replaces every line feed by the given line
+// ending sequence before writing the data to the wrapped write closer.
+func NewLineEndingWriteCloser(writer io.WriteCloser, lineEnding string) io.WriteCloser {
+	return lineEndingWriteCloser{
+		lineEndingWriter: lineEndingWriter{
+			writer:     writer,
+			lineEnding: []byte(lineEnding),
+		},
+		closer: writer,
+	}
+}
+
+// Write writes the data with translated line endings, the returned count refers to the passed data.
+func (w lineEndingWriter) Write(p []byte) (int, error) {
+	if _, err := w.writer.Write(bytes.ReplaceAll(p, []byte{'\n'}, w.lineEnding)); err != nil {
+		return 0, fmt.Errorf("writing data: %w", err)
+	}
+	return len(p), nil
+}
+
+// Close closes the wrapped writer.
+func (w lineEndingWriteCloser) Close() error {
+	if err := w.closer.Close(); err != nil {
+		return fmt.Errorf("closing writer: %w", err)
+	}
+	return nil
+}
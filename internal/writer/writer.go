@@ -7,13 +7,22 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/retroenv/nesgodisasm/internal/assembler"
 	"github.com/retroenv/nesgodisasm/internal/program"
 )
 
-const dataBytesPerLine = 16
+const (
+	chrSummaryTilesPerLine = 32
+	dataBytesPerLine       = 16
+	dataRunBlockNaming     = "data_%04x_%d"
+)
 
 type lineWriterFunc func(line string, byteCount int) error
 
+// lineCommentFunc returns the comment of the next data line that contains the given number of
+// bytes and the width that the data part of the line gets padded to before the comment.
+type lineCommentFunc func(byteCount int) (string, int)
+
 // AssemblerWriter defines a shared interface used by the different assembler compatibility packages.
 // Their constructors need to return this shared interface, having them return the actual type instead of
 // the interface results in compiler errors for the constructor variable that they are assigned to.
@@ -30,8 +39,19 @@ type Writer struct {
 
 // Options of the writer.
 type Options struct {
-	DirectivePrefix string // nesasm requires a space before a directive
-	OffsetComments  bool
+	DirectivePrefix      string // nesasm requires a space before a directive
+	AddressPrefix        bool   // prefix every code and data line with an address marker
+	AliasDecimal         bool   // append the decimal value of aliases as comment
+	BankChecksums        bool   // write the CRC32 checksum of every PRG bank at its start
+	CanonicalOperands    bool   // operands are output in the assembler neutral syntax
+	GroupDataByReference bool   // split combined data bytes at referenced addresses that have their own label
+	HexdumpData          bool   // append a hexdump style ASCII gutter to data lines
+	MaxDataRun           int    // split data runs into labeled blocks of this maximum size, 0 disables splitting
+	MaxLineLength        int    // maximum length of data lines including comments, 0 disables the limit
+	OffsetComments       bool
+	Provenance           bool   // write the source file name and its checksum to the comment header
+	SourceFile           string // name of the disassembled input file
+	SymbolicOffsets      bool   // write data bytes that are label offsets from the data start as label difference
 }
 
 // New creates a new writer.
@@ -43,8 +63,15 @@ func New(app *program.Program, writer io.Writer, options Options) *Writer {
 	}
 }
 
-// ProcessPRG processes the PRG segment and writes all code offsets, labels and their comments.
+// ProcessPRG processes the PRG segment and writes all code offsets, labels and their comments,
+// optionally preceded by the checksum of the bank.
 func (w Writer) ProcessPRG(bank *program.PRGBank, endIndex int) error {
+	if w.options.BankChecksums {
+		if _, err := fmt.Fprintf(w.writer, "; bank CRC32: %08X\n\n", bank.Checksum); err != nil {
+			return fmt.Errorf("writing bank checksum: %w", err)
+		}
+	}
+
 	var previousLineWasCode bool
 
 	for i := 0; i < endIndex; i++ {
@@ -78,27 +105,32 @@ func (w Writer) ProcessPRG(bank *program.PRGBank, endIndex int) error {
 }
 
 // BundleDataWrites bundles writes of data bytes to print dataBytesPerLine bytes per line.
+// Less bytes per line are printed if the line would exceed the configured maximum line length.
 func (w Writer) BundleDataWrites(data []byte, lineWriter lineWriterFunc) error {
+	return w.bundleDataWrites(data, nil, nil, lineWriter)
+}
+
+// bundleDataWrites bundles writes of data bytes, bytes that have a non empty expression at the
+// same index are written as the expression instead of their value. The optional line comment
+// function returns the comment of every line, which is included in the maximum line length.
+func (w Writer) bundleDataWrites(data []byte, expressions []string, lineComment lineCommentFunc,
+	lineWriter lineWriterFunc) error {
+
+	bytesPerLine := w.bytesPerLine()
 	remaining := len(data)
 	for i := 0; remaining > 0; {
-		toWrite := remaining
-		if toWrite > dataBytesPerLine {
-			toWrite = dataBytesPerLine
-		}
-
-		buf := &strings.Builder{}
-		if _, err := fmt.Fprintf(buf, "%s.byte ", w.options.DirectivePrefix); err != nil {
-			return fmt.Errorf("writing data prefix: %w", err)
+		toWrite := min(remaining, bytesPerLine)
+		var lineExpressions []string
+		if expressions != nil {
+			lineExpressions = expressions[i:]
 		}
 
-		for j := range toWrite {
-			if _, err := fmt.Fprintf(buf, "$%02x, ", data[i+j]); err != nil {
-				return fmt.Errorf("writing data byte: %w", err)
-			}
+		line := w.dataLine(data[i:i+toWrite], lineExpressions)
+		for toWrite > 1 && !w.fitsLine(line, toWrite, lineComment) {
+			toWrite--
+			line = w.dataLine(data[i:i+toWrite], lineExpressions)
 		}
 
-		line := strings.TrimRight(buf.String(), ", ")
-
 		if lineWriter != nil {
 			if err := lineWriter(line, toWrite); err != nil {
 				return fmt.Errorf("writing data line using custom writer: %w", err)
@@ -126,6 +158,75 @@ func (w Writer) OutputAliasMap(aliases map[string]uint16) error {
 		return fmt.Errorf("writing line: %w", err)
 	}
 
+	if err := w.writeAliases(aliases); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(w.writer); err != nil {
+		return fmt.Errorf("writing line: %w", err)
+	}
+	return nil
+}
+
+// OutputGroupedAliasMap outputs an alias map grouped by the given group names of the aliases, every group
+// is preceded by a section comment. The groups are ordered by their lowest address, aliases without
+// a group are output last.
+func (w Writer) OutputGroupedAliasMap(aliases map[string]uint16, groups map[string]string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	groupAliases := map[string]map[string]uint16{}
+	groupAddresses := map[string]uint16{}
+	for name, address := range aliases {
+		group := groups[name]
+		if groupAliases[group] == nil {
+			groupAliases[group] = map[string]uint16{}
+			groupAddresses[group] = address
+		}
+		groupAliases[group][name] = address
+		groupAddresses[group] = min(groupAddresses[group], address)
+	}
+
+	groupNames := make([]string, 0, len(groupAliases))
+	for group := range groupAliases {
+		groupNames = append(groupNames, group)
+	}
+	slices.SortFunc(groupNames, func(a, b string) int {
+		if (a == "") != (b == "") {
+			if a == "" {
+				return 1
+			}
+			return -1
+		}
+		if groupAddresses[a] != groupAddresses[b] {
+			return int(groupAddresses[a]) - int(groupAddresses[b])
+		}
+		return strings.Compare(a, b)
+	})
+
+	if _, err := fmt.Fprintln(w.writer); err != nil {
+		return fmt.Errorf("writing line: %w", err)
+	}
+
+	for _, group := range groupNames {
+		if group != "" {
+			if _, err := fmt.Fprintf(w.writer, "; %s\n", group); err != nil {
+				return fmt.Errorf("writing alias group: %w", err)
+			}
+		}
+		if err := w.writeAliases(groupAliases[group]); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w.writer); err != nil {
+			return fmt.Errorf("writing line: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeAliases writes the aliases sorted by name.
+func (w Writer) writeAliases(aliases map[string]uint16) error {
 	// sort the aliases by name before outputting to avoid random map order
 	names := make([]string, 0, len(aliases))
 	for constant := range aliases {
@@ -135,10 +236,50 @@ func (w Writer) OutputAliasMap(aliases map[string]uint16) error {
 
 	for _, constant := range names {
 		address := aliases[constant]
-		if _, err := fmt.Fprintf(w.writer, "%s = $%04X\n", constant, address); err != nil {
+		line := fmt.Sprintf("%s = $%04X", constant, address)
+		if w.options.AliasDecimal {
+			line = fmt.Sprintf("%-30s ; %d", line, address)
+		}
+		if _, err := fmt.Fprintln(w.writer, line); err != nil {
 			return fmt.Errorf("writing alias: %w", err)
 		}
 	}
+	return nil
+}
+
+// WriteCHRSummary writes a tile index table of the CHR data as comments, every tile is marked
+// as non-blank or blank to give an overview of the used tiles.
+func (w Writer) WriteCHRSummary() error {
+	chr := w.app.CHR
+	tileCount := chr.TileCount()
+
+	var rows []string
+	row := &strings.Builder{}
+	var nonBlank int
+
+	for i := range tileCount {
+		if chr.BlankTile(i) {
+			row.WriteByte('.')
+		} else {
+			row.WriteByte('#')
+			nonBlank++
+		}
+
+		if (i+1)%chrSummaryTilesPerLine == 0 || i == tileCount-1 {
+			rows = append(rows, row.String())
+			row.Reset()
+		}
+	}
+
+	if _, err := fmt.Fprintf(w.writer, "; CHR summary: %d tiles, %d non-blank, %d blank (# = non-blank, . = blank)\n",
+		tileCount, nonBlank, tileCount-nonBlank); err != nil {
+		return fmt.Errorf("writing CHR summary: %w", err)
+	}
+	for i, row := range rows {
+		if _, err := fmt.Fprintf(w.writer, "; $%03X: %s\n", i*chrSummaryTilesPerLine, row); err != nil {
+			return fmt.Errorf("writing CHR summary: %w", err)
+		}
+	}
 
 	if _, err := fmt.Fprintln(w.writer); err != nil {
 		return fmt.Errorf("writing line: %w", err)
@@ -146,8 +287,91 @@ func (w Writer) OutputAliasMap(aliases map[string]uint16) error {
 	return nil
 }
 
-// WriteCommentHeader writes the CRC32 checksums and code base address as comments to the output.
+// WriteCHRFile writes the raw CHR data to a separate binary file that gets created by the bank
+// writer constructor and includes it in the output.
+func (w Writer) WriteCHRFile(newBankWriter assembler.NewBankWriter, fileName string) error {
+	chrWriter, err := newBankWriter(fileName)
+	if err != nil {
+		return fmt.Errorf("creating CHR file: %w", err)
+	}
+	if _, err := chrWriter.Write(w.app.CHR); err != nil {
+		_ = chrWriter.Close()
+		return fmt.Errorf("writing CHR file: %w", err)
+	}
+	if err := chrWriter.Close(); err != nil {
+		return fmt.Errorf("closing CHR file: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w.writer, "%s.incbin \"%s\"\n", w.options.DirectivePrefix, fileName); err != nil {
+		return fmt.Errorf("writing CHR include: %w", err)
+	}
+	return nil
+}
+
+// WriteCrossReferenceIndex writes an index of all labels of all banks and the addresses of the
+// code that branches to them as comments.
+func (w Writer) WriteCrossReferenceIndex() error {
+	if _, err := fmt.Fprintln(w.writer, "\n; === Cross References ==="); err != nil {
+		return fmt.Errorf("writing cross reference index: %w", err)
+	}
+
+	for _, bank := range w.app.PRG {
+		for _, offset := range bank.Offsets {
+			if offset.Label == "" || len(offset.References) == 0 {
+				continue
+			}
+
+			references := make([]string, 0, len(offset.References))
+			for _, address := range offset.References {
+				references = append(references, fmt.Sprintf("$%04X", address))
+			}
+
+			kind := "branched from"
+			if offset.IsType(program.CallDestination) {
+				kind = "called from"
+			}
+
+			if _, err := fmt.Fprintf(w.writer, "; %s ($%04X): %s %s\n",
+				offset.Label, offset.Address, kind, strings.Join(references, ", ")); err != nil {
+				return fmt.Errorf("writing cross reference index: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteTableOfContents writes a list of all function labels of all banks and their addresses
+// as comments.
+func (w Writer) WriteTableOfContents() error {
+	if _, err := fmt.Fprintln(w.writer, "; === Table of Contents ==="); err != nil {
+		return fmt.Errorf("writing table of contents: %w", err)
+	}
+
+	for _, bank := range w.app.PRG {
+		for _, offset := range bank.Offsets {
+			if offset.Label == "" || !offset.IsType(program.CallDestination) {
+				continue
+			}
+			if _, err := fmt.Fprintf(w.writer, "; %s ($%04X)\n", offset.Label, offset.Address); err != nil {
+				return fmt.Errorf("writing table of contents: %w", err)
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintln(w.writer); err != nil {
+		return fmt.Errorf("writing line: %w", err)
+	}
+	return nil
+}
+
+// WriteCommentHeader writes the CRC32 checksums and code base address as comments to the output,
+// optionally preceded by the name of the source file.
 func (w Writer) WriteCommentHeader() error {
+	if w.options.Provenance {
+		if _, err := fmt.Fprintf(w.writer, "; Source: %s (CRC32 %08x)\n", w.options.SourceFile, w.app.Checksums.Overall); err != nil {
+			return fmt.Errorf("writing provenance: %w", err)
+		}
+	}
 	if _, err := fmt.Fprintf(w.writer, "; PRG CRC32 checksum: %08x\n", w.app.Checksums.PRG); err != nil {
 		return fmt.Errorf("writing prg checksum: %w", err)
 	}
@@ -157,9 +381,17 @@ func (w Writer) WriteCommentHeader() error {
 	if _, err := fmt.Fprintf(w.writer, "; Overall CRC32 checksum: %08x\n", w.app.Checksums.Overall); err != nil {
 		return fmt.Errorf("writing overall checksum: %w", err)
 	}
-	if _, err := fmt.Fprintf(w.writer, "; Code base address: $%04x\n\n", w.app.CodeBaseAddress); err != nil {
+	if _, err := fmt.Fprintf(w.writer, "; Code base address: $%04x\n", w.app.CodeBaseAddress); err != nil {
 		return fmt.Errorf("writing code base address: %w", err)
 	}
+	if w.options.CanonicalOperands {
+		if _, err := fmt.Fprintf(w.writer, "; %s\n", assembler.CanonicalOperandsDescription); err != nil {
+			return fmt.Errorf("writing operand syntax: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintln(w.writer); err != nil {
+		return fmt.Errorf("writing line: %w", err)
+	}
 	return nil
 }
 
@@ -215,6 +447,10 @@ func (w Writer) writeLabel(index int, offset program.Offset) error {
 }
 
 func (w Writer) writeCodeLine(offset program.Offset) error {
+	if err := w.writeAddressPrefix(offset.Address); err != nil {
+		return err
+	}
+
 	if offset.Comment == "" {
 		if _, err := fmt.Fprintf(w.writer, "  %s\n", offset.Code); err != nil {
 			return fmt.Errorf("writing line: %w", err)
@@ -227,6 +463,18 @@ func (w Writer) writeCodeLine(offset program.Offset) error {
 	return nil
 }
 
+// writeAddressPrefix writes a machine parseable address marker at the beginning of a line,
+// if enabled by the options.
+func (w Writer) writeAddressPrefix(address uint16) error {
+	if !w.options.AddressPrefix {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w.writer, "@%04X ", address); err != nil {
+		return fmt.Errorf("writing address prefix: %w", err)
+	}
+	return nil
+}
+
 // bundlePRGDataWrites parses PRG to create bundled writes of data bytes per line.
 func (w Writer) bundlePRGDataWrites(bank *program.PRGBank, startIndex, endIndex int) (int, error) {
 	data := getPrgData(bank, startIndex, endIndex)
@@ -235,23 +483,35 @@ func (w Writer) bundlePRGDataWrites(bank *program.PRGBank, startIndex, endIndex
 	}
 
 	currentIndex := startIndex
+	hexdumpWidth := dataLineLength(w.options.DirectivePrefix, w.hexdumpBytesPerLine())
+	lineComment := func(byteCount int) (string, int) {
+		offset := bank.Offsets[currentIndex]
+		comment := offset.Comment
+		lineWidth := 32
+		if w.options.HexdumpData {
+			position := currentIndex - startIndex
+			comment = program.JoinComments(asciiGutter(data[position:position+byteCount]), comment)
+			// align the gutters of lines that contain less bytes than a full line
+			lineWidth = hexdumpWidth
+		}
+		if w.options.OffsetComments && !offset.HasAddressComment {
+			comment = program.JoinComments(fmt.Sprintf("$%04X", offset.Address), comment)
+		}
+		return comment, lineWidth
+	}
+
 	lineWriter := func(line string, byteCount int) error {
 		var err error
 
-		offset := bank.Offsets[currentIndex]
-		if w.options.OffsetComments && !offset.HasAddressComment {
-			comment := fmt.Sprintf("$%04X", offset.Address)
-			if offset.Comment == "" {
-				offset.Comment = comment
-			} else {
-				offset.Comment = comment + "  " + offset.Comment
-			}
+		if err := w.writeAddressPrefix(bank.Offsets[currentIndex].Address); err != nil {
+			return err
 		}
 
-		if offset.Comment == "" {
+		comment, lineWidth := lineComment(byteCount)
+		if comment == "" {
 			_, err = fmt.Fprintf(w.writer, "%s\n", line)
 		} else {
-			_, err = fmt.Fprintf(w.writer, "%-32s ; %s\n", line, offset.Comment)
+			_, err = fmt.Fprintf(w.writer, "%-*s ; %s\n", lineWidth, line, comment)
 		}
 		if err != nil {
 			return fmt.Errorf("writing prg line: %w", err)
@@ -261,13 +521,208 @@ func (w Writer) bundlePRGDataWrites(bank *program.PRGBank, startIndex, endIndex
 		return nil
 	}
 
-	if err := w.BundleDataWrites(data, lineWriter); err != nil {
-		return 0, fmt.Errorf("writing PRG data: %w", err)
+	parts := [][]byte{data}
+	if w.options.GroupDataByReference {
+		parts = referencedDataParts(bank, startIndex, data)
 	}
 
+	for i, part := range parts {
+		if i > 0 {
+			// the referenced part starts with its own label
+			if err := w.writeLabel(currentIndex, bank.Offsets[currentIndex]); err != nil {
+				return 0, err
+			}
+		}
+		if err := w.writePRGData(bank, currentIndex, part, lineComment, lineWriter); err != nil {
+			return 0, err
+		}
+	}
 	return len(data), nil
 }
 
+// writePRGData writes the data bytes that start at the given index, split into labeled blocks
+// if the data exceeds the maximum data run size.
+func (w Writer) writePRGData(bank *program.PRGBank, startIndex int, data []byte, lineComment lineCommentFunc,
+	lineWriter lineWriterFunc) error {
+
+	if w.options.MaxDataRun <= 0 || len(data) <= w.options.MaxDataRun {
+		var expressions []string
+		if w.options.SymbolicOffsets {
+			expressions = symbolicOffsets(bank, startIndex, bank.Offsets[startIndex].Label, data)
+		}
+		if err := w.bundleDataWrites(data, expressions, lineComment, lineWriter); err != nil {
+			return fmt.Errorf("writing PRG data: %w", err)
+		}
+		return nil
+	}
+
+	return w.writeDataRunBlocks(bank, startIndex, data, lineComment, lineWriter)
+}
+
+// referencedDataParts splits the data bytes that start at the given index at all offsets that
+// have a label. This splits combined data bytes like disambiguous instructions at the
+// referenced addresses inside of them.
+func referencedDataParts(bank *program.PRGBank, startIndex int, data []byte) [][]byte {
+	var parts [][]byte
+	start := 0
+	for i := 1; i < len(data); i++ {
+		if bank.Offsets[startIndex+i].Label == "" {
+			continue
+		}
+		parts = append(parts, data[start:i])
+		start = i
+	}
+	return append(parts, data[start:])
+}
+
+// symbolicOffsets returns the label differences for all data bytes that are an offset from the given
+// base label of the data start to another label of the bank, for example "target-table". All other
+// data bytes have an empty expression, nil is returned if no data byte has an expression.
+func symbolicOffsets(bank *program.PRGBank, startIndex int, base string, data []byte) []string {
+	if base == "" {
+		return nil
+	}
+
+	var expressions []string
+	for i, value := range data {
+		targetIndex := startIndex + int(value)
+		if value == 0 || targetIndex >= len(bank.Offsets) || bank.Offsets[targetIndex].Label == "" {
+			continue
+		}
+
+		if expressions == nil {
+			expressions = make([]string, len(data))
+		}
+		expressions[i] = bank.Offsets[targetIndex].Label + "-" + base
+	}
+	return expressions
+}
+
+// writeDataRunBlocks splits a data run into blocks of the configured maximum size and
+// writes every block with its own label. The first block keeps the label of the run, if it
+// has one, instead of getting a generated one.
+func (w Writer) writeDataRunBlocks(bank *program.PRGBank, startIndex int, data []byte,
+	lineComment lineCommentFunc, lineWriter lineWriterFunc) error {
+
+	start := bank.Offsets[startIndex]
+	for block := 0; len(data) > 0; block++ {
+		size := min(len(data), w.options.MaxDataRun)
+
+		if block > 0 {
+			if _, err := fmt.Fprintln(w.writer); err != nil {
+				return fmt.Errorf("writing line: %w", err)
+			}
+		}
+		label := start.Label
+		if block > 0 || label == "" {
+			label = fmt.Sprintf(dataRunBlockNaming, start.Address, block)
+			if _, err := fmt.Fprintf(w.writer, "%s:\n", label); err != nil {
+				return fmt.Errorf("writing data block label: %w", err)
+			}
+		}
+
+		var expressions []string
+		if w.options.SymbolicOffsets {
+			expressions = symbolicOffsets(bank, startIndex+block*w.options.MaxDataRun, label, data[:size])
+		}
+		if err := w.bundleDataWrites(data[:size], expressions, lineComment, lineWriter); err != nil {
+			return fmt.Errorf("writing PRG data: %w", err)
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// dataLine returns the data line of the given bytes, bytes that have a non empty expression at the
+// same index are written as the expression instead of their value.
+func (w Writer) dataLine(data []byte, expressions []string) string {
+	buf := &strings.Builder{}
+	buf.WriteString(w.options.DirectivePrefix + ".byte ")
+
+	for i, b := range data {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if expressions != nil && expressions[i] != "" {
+			buf.WriteString(expressions[i])
+			continue
+		}
+		fmt.Fprintf(buf, "$%02x", b)
+	}
+	return buf.String()
+}
+
+// fitsLine returns whether the data line containing the given number of bytes does not exceed the
+// maximum line length, including the address prefix and the comment of the line.
+func (w Writer) fitsLine(line string, byteCount int, lineComment lineCommentFunc) bool {
+	if w.options.MaxLineLength <= 0 {
+		return true
+	}
+
+	length := len(line)
+	if lineComment != nil {
+		if comment, width := lineComment(byteCount); comment != "" {
+			length = max(length, width) + len(" ; ") + len(comment)
+		}
+	}
+	if w.options.AddressPrefix {
+		length += len("@0000 ")
+	}
+	return length <= w.options.MaxLineLength
+}
+
+// bytesPerLine returns the number of data bytes to print per line, capped by the maximum line length.
+func (w Writer) bytesPerLine() int {
+	if w.options.MaxLineLength <= 0 {
+		return dataBytesPerLine
+	}
+
+	count := dataBytesPerLine
+	for count > 1 && dataLineLength(w.options.DirectivePrefix, count) > w.options.MaxLineLength {
+		count--
+	}
+	return count
+}
+
+// hexdumpBytesPerLine returns the number of data bytes to print per line with a hexdump style
+// ASCII gutter, capped by the maximum line length including the gutter and the offset comment.
+func (w Writer) hexdumpBytesPerLine() int {
+	count := w.bytesPerLine()
+	if w.options.MaxLineLength <= 0 {
+		return count
+	}
+
+	var fixedLength int
+	if w.options.OffsetComments {
+		fixedLength += len("$0000  ")
+	}
+	if w.options.AddressPrefix {
+		fixedLength += len("@0000 ")
+	}
+	for count > 1 && fixedLength+dataLineLength(w.options.DirectivePrefix, count)+len(" ; ||")+count > w.options.MaxLineLength {
+		count--
+	}
+	return count
+}
+
+// dataLineLength returns the length of a data line containing the given number of bytes.
+func dataLineLength(directivePrefix string, count int) int {
+	return len(directivePrefix) + len(".byte ") + count*len("$00, ") - len(", ")
+}
+
+// asciiGutter returns the hexdump style ASCII representation of the data bytes, bytes that
+// are not printable are represented by a dot.
+func asciiGutter(data []byte) string {
+	buf := make([]byte, len(data))
+	for i, b := range data {
+		if b < 0x20 || b > 0x7e {
+			b = '.'
+		}
+		buf[i] = b
+	}
+	return "|" + string(buf) + "|"
+}
+
 func getPrgData(bank *program.PRGBank, startIndex, endIndex int) []byte {
 	var data []byte
 
@@ -289,6 +744,11 @@ func getPrgData(bank *program.PRGBank, startIndex, endIndex int) []byte {
 		}
 
 		data = append(data, offset.Data...)
+
+		// stop at the end of a data block with a detected size
+		if offset.IsType(program.DataBlockEnd) {
+			break
+		}
 	}
 
 	return data
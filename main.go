@@ -2,11 +2,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 
@@ -16,15 +20,20 @@ import (
 	"github.com/retroenv/nesgodisasm/internal/assembler/asm6"
 	"github.com/retroenv/nesgodisasm/internal/assembler/ca65"
 	"github.com/retroenv/nesgodisasm/internal/assembler/nesasm"
+	"github.com/retroenv/nesgodisasm/internal/csvexport"
+	"github.com/retroenv/nesgodisasm/internal/mapper"
 	"github.com/retroenv/nesgodisasm/internal/options"
 	"github.com/retroenv/nesgodisasm/internal/program"
+	"github.com/retroenv/nesgodisasm/internal/symbols"
 	"github.com/retroenv/nesgodisasm/internal/verification"
 	"github.com/retroenv/retrogolib/arch/nes/cartridge"
-	"github.com/retroenv/retrogolib/arch/nes/parameter"
 	"github.com/retroenv/retrogolib/buildinfo"
 	"github.com/retroenv/retrogolib/log"
 )
 
+// exitCodeFailure is the exit code of -analyze-only if a file could not be disassembled.
+const exitCodeFailure = 1
+
 var (
 	version = "dev"
 	commit  = ""
@@ -42,6 +51,10 @@ func main() {
 		logger.Fatal(err.Error())
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var exitCode int
 	for _, file := range files {
 		opts.Input = file
 		if len(files) > 1 || opts.Output == "" {
@@ -49,16 +62,32 @@ func main() {
 			opts.Output = file[:len(file)-len(filepath.Ext(file))] + ".asm"
 		}
 
-		if err := disasmFile(logger, opts, disasmOptions); err != nil {
+		err := disasmFile(ctx, logger, opts, disasmOptions)
+		var qualityErr *disasm.QualityError
+		switch {
+		case errors.As(err, &qualityErr):
+			logger.Error("Quality check failed", log.String("file", file), log.Err(err))
+			exitCode = max(exitCode, qualityErr.ExitCode)
+		case err != nil:
 			logger.Error("Disassembling failed", log.Err(err))
+			if opts.AnalyzeOnly {
+				exitCode = max(exitCode, exitCodeFailure)
+			}
 		}
 	}
+
+	if exitCode != 0 {
+		stop()
+		os.Exit(exitCode)
+	}
 }
 
 func initializeApp() (*log.Logger, options.Program, options.Disassembler) {
 	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	var opts options.Program
 	readOptionFlags(flags, &opts)
+	disasmOptions := options.NewDisassembler("")
+	readDisasmOptionFlags(flags, &disasmOptions)
 
 	logger := createLogger(opts.Debug, opts.Quiet)
 	err := flags.Parse(os.Args[1:])
@@ -73,11 +102,7 @@ func initializeApp() (*log.Logger, options.Program, options.Disassembler) {
 
 	for i, arg := range args {
 		if i > 0 && arg[0] == '-' {
-			fmt.Printf("Potential argument %s found after file to disassemble, please pass the file to disassemble as last argument\n\n", arg)
-			fmt.Printf("usage: nesgodisasm [options] <file to disassemble>\n\n")
-			flags.PrintDefaults()
-			fmt.Println()
-			os.Exit(1)
+			exitWithUsage(flags, fmt.Sprintf("Potential argument %s found after file to disassemble, please pass the file to disassemble as last argument", arg))
 		}
 	}
 
@@ -94,28 +119,121 @@ func initializeApp() (*log.Logger, options.Program, options.Disassembler) {
 		opts.Input = args[0]
 	}
 
-	disasmOptions := options.NewDisassembler(opts.Assembler)
+	if disasmOptions.Unreachable != "" && disasmOptions.Unreachable != options.UnreachableCode &&
+		disasmOptions.Unreachable != options.UnreachableData {
+
+		exitWithUsage(flags, fmt.Sprintf("Unsupported unreachable code mode '%s'", disasmOptions.Unreachable))
+	}
+
+	if disasmOptions.CHRFile != "" && filepath.Ext(disasmOptions.CHRFile) == "" {
+		exitWithUsage(flags, fmt.Sprintf("CHR file name '%s' requires a file extension", disasmOptions.CHRFile))
+	}
+
+	if disasmOptions.LineEndings != options.LineEndingsLF && disasmOptions.LineEndings != options.LineEndingsCRLF {
+		exitWithUsage(flags, fmt.Sprintf("Unsupported line endings '%s'", disasmOptions.LineEndings))
+	}
+
+	if opts.AnalyzeOnly {
+		// enable the detectors whose warnings are part of the quality metrics
+		disasmOptions.BankCrossingWarnings = true
+		disasmOptions.StackCheck = true
+	}
+
+	disasmOptions.Assembler = opts.Assembler
 	disasmOptions.NoUnofficialInstructions = noUnofficialInstructions
-	readDisasmOptionFlags(flags, &disasmOptions)
 
 	return logger, opts, disasmOptions
 }
 
+// exitWithUsage prints the message followed by the usage of the program and exits.
+func exitWithUsage(flags *flag.FlagSet, message string) {
+	fmt.Printf("%s\n\n", message)
+	fmt.Printf("usage: nesgodisasm [options] <file to disassemble>\n\n")
+	flags.PrintDefaults()
+	fmt.Println()
+	os.Exit(1)
+}
+
 func readOptionFlags(flags *flag.FlagSet, opts *options.Program) {
+	flags.BoolVar(&opts.AnalyzeOnly, "analyze-only", false, "only disassemble without writing the .asm file and exit with a distinct code if a quality threshold is exceeded")
 	flags.StringVar(&opts.Assembler, "a", "ca65", "Assembler compatibility of the generated .asm file (asm6/ca65/nesasm)")
 	flags.BoolVar(&opts.Binary, "binary", false, "read input file as raw binary file without any header")
 	flags.StringVar(&opts.Batch, "batch", "", "process a batch of given path and file mask and automatically .asm file naming, for example *.nes")
 	flags.StringVar(&opts.Config, "c", "", "Config file name to write output to for ca65 assembler")
+	flags.BoolVar(&opts.Coverage, "coverage", false, "log a summary of the PRG bytes classified as code and data")
 	flags.BoolVar(&opts.Debug, "debug", false, "enable debugging options for extended logging")
+	flags.BoolVar(&opts.EmitMakefile, "emit-makefile", false, "write a Makefile next to the output file that rebuilds the ROM with the chosen assembler")
 	flags.StringVar(&opts.CodeDataLog, "cdl", "", "name of the .cdl Code/Data log file to load")
 	flags.BoolVar(&opts.NoHexComments, "nohexcomments", false, "do not output opcode bytes as hex values in comments")
 	flags.BoolVar(&opts.NoOffsets, "nooffsets", false, "do not output offsets in comments")
+	flags.StringVar(&opts.ExportCSV, "export-csv", "", "name of a CSV file to write every offset to with its address, type, label, code and comment")
+	flags.StringVar(&opts.KnownFuncs, "known-funcs", "", "name of a file with known functions in the format crc32 name [library] per line that get labeled when recognized")
+	flags.StringVar(&opts.CSVFile, "only-csv-file", "", "name of a CSV file to write every offset to instead of generating the .asm file")
+	flags.StringVar(&opts.LabelsFile, "only-labels-file", "", "name of the labels file to write instead of generating the .asm file")
+	flags.IntVar(&opts.Quality.MaxBankCrossings, "max-bank-crossings", 0, "maximum number of branches and jumps into a different bank window accepted by -analyze-only")
+	flags.Float64Var(&opts.Quality.MaxForcedPercent, "max-forced-percent", 10, "maximum percentage of PRG bytes forced to be data by excluded ranges or ambiguous instructions accepted by -analyze-only")
+	flags.IntVar(&opts.Quality.MaxStackImbalances, "max-stack-imbalances", 0, "maximum number of stack imbalances accepted by -analyze-only")
+	flags.StringVar(&opts.Nodes, "nodes", "", "name of a JSON file to write every instruction to as node with its address, successors and whether it is a call")
+	flags.BoolVar(&opts.OpcodeHistogram, "opcode-histogram", false, "log the number of decoded instructions per opcode")
 	flags.StringVar(&opts.Output, "o", "", "name of the output .asm file, printed on console if no name given")
 	flags.BoolVar(&opts.Quiet, "q", false, "perform operations quietly")
+	flags.StringVar(&opts.RenameMap, "rename-map", "", "name of a file with label renames in the format old=new per line, for example _label_8003=init_ppu")
+	flags.DurationVar(&opts.Timeout, "timeout", 0, "maximum duration of the disassembly of a file, for example 30s (0 disables the timeout)")
 	flags.BoolVar(&opts.AssembleTest, "verify", false, "verify the generated output by assembling with ca65 and check if it matches the input")
 }
 
 func readDisasmOptionFlags(flags *flag.FlagSet, opts *options.Disassembler) {
+	flags.BoolVar(&opts.AddressPrefix, "address-prefix", false, "prefix every code and data line with a machine parseable @address marker")
+	flags.BoolVar(&opts.AliasDecimal, "alias-decimal", false, "append the decimal value of constants and variables as comment")
+	flags.BoolVar(&opts.AnnotateAddressing, "annotate-addressing", false, "annotate every instruction with its addressing mode")
+	flags.BoolVar(&opts.AnnotateChecksum, "annotate-checksum", false, "annotate loops that accumulate sequential PRG bytes as ROM checksum")
+	flags.BoolVar(&opts.AnnotateIndexed, "annotate-indexed", false, "annotate absolute indexed instructions with their base address and index register")
+	flags.BoolVar(&opts.AnnotateMMC1, "annotate-mmc1", false, "annotate MMC1 serial register writes for mapper 1 ROMs")
+	flags.BoolVar(&opts.BankChecksums, "bank-checksums", false, "annotate the start of every PRG bank with the CRC32 checksum of its bytes")
+	flags.BoolVar(&opts.BankCrossingWarnings, "bank-crossing-warnings", false, "annotate branches and jumps whose destination is mapped from a different bank than the source")
+	flags.BoolVar(&opts.BankScopes, "bank-scopes", false, "wrap the labels of every bank in a separate scope and qualify cross bank references (ca65 only)")
+	flags.BoolVar(&opts.BranchOffsetComments, "branch-offset-comments", false, "annotate relative branches with their signed offset")
+	flags.BoolVar(&opts.CanonicalOperands, "canonicalize-operands", false, "output operands in an assembler neutral syntax without address size prefixes that is described in the comment header, not supported for nesasm, the output may not assemble to identical bytes")
+	flags.StringVar(&opts.CHRFile, "chr-file", "", "name of a binary file to write the CHR data to that gets included instead of inline data (ca65 and asm6 only)")
+	flags.BoolVar(&opts.CHRSummary, "chr-summary", false, "output a table of blank and non-blank CHR tiles as comments")
+	flags.BoolVar(&opts.CollapseIdenticalFunctions, "collapse-identical-functions", false, "annotate functions that are byte identical to a previous function")
+	flags.StringVar(&opts.EntryLabel, "entry-label", "", "label name of the entry point, defaults to Reset")
+	flags.Func("exclude", "address range to exclude from code analysis, for example 0x9000-0x9FFF (can be repeated)", func(s string) error {
+		addressRange, err := options.ParseAddressRange(s)
+		if err != nil {
+			return fmt.Errorf("parsing exclude option: %w", err)
+		}
+		opts.Exclude = append(opts.Exclude, addressRange)
+		return nil
+	})
+	flags.BoolVar(&opts.Exports, "exports", false, "export all function labels and import all undefined referenced symbols (ca65 only)")
+	flags.BoolVar(&opts.FileOffsets, "file-offsets", false, "annotate every instruction with its offset in the input file")
+	flags.BoolVar(&opts.FunctionMetrics, "function-metrics", false, "annotate function labels with their number of instructions and branches")
+	flags.BoolVar(&opts.GroupDataByReference, "group-data-by-reference", false, "split data regions at every referenced address to give each referenced part its own label")
+	flags.BoolVar(&opts.HexdumpData, "hexdump-data", false, "append a hexdump style ASCII column to data lines")
+	flags.StringVar(&opts.LineEndings, "line-endings", options.LineEndingsLF, "line endings of the output (lf/crlf)")
+	flags.IntVar(&opts.MaxDataRun, "max-data-run", 0, "split data runs into labeled blocks of at most this many bytes")
+	flags.IntVar(&opts.MaxLineLength, "max-line-length", 0, "maximum length of data lines including comments, less bytes are output per line to stay within the limit")
+	flags.IntVar(&opts.MinJumpTableEntries, "min-jumptable-entries", 0, "minimum number of valid entries of a jump engine function table, smaller tables are treated as data")
+	flags.BoolVar(&opts.NoAutoLabels, "no-auto-labels", false, "do not generate label and variable names, reference raw addresses unless a name is given by the rename map")
+	flags.BoolVar(&opts.Provenance, "provenance", false, "write the input file name and its CRC32 checksum to the comment header")
+	flags.BoolVar(&opts.SeedFromCDLCalls, "seed-from-cdl-calls", false, "parse the sub entry points of the Code/Data log file as functions")
+	flags.BoolVar(&opts.SplitHeader, "split-header", false, "write the iNES header to a separate header.inc file that gets included (ca65 only)")
+	flags.BoolVar(&opts.StackCheck, "stack-check", false, "annotate functions whose stack pushes and pulls are not balanced along a code path")
+	flags.BoolVar(&opts.SymbolicOffsets, "symbolic-offsets", false, "output data bytes that are offsets from the label of the data start to another label as label difference")
+	flags.BoolVar(&opts.TableOfContents, "toc", false, "output a table of contents of all function labels and their addresses before the code")
+	flags.StringVar(&opts.Unreachable, "unreachable", "", "output mode of code following complementary branches (code/data), it is not detected if not set")
+	flags.StringVar(&opts.UnreachableComment, "unreachable-comment", opts.UnreachableComment, "comment of code following complementary branches")
+	flags.Func("word-table", "table of little endian word pointers to code as address:entries, for example 0x8015:8 (can be repeated)", func(s string) error {
+		table, err := options.ParseWordTable(s)
+		if err != nil {
+			return fmt.Errorf("parsing word table option: %w", err)
+		}
+		opts.WordTables = append(opts.WordTables, table)
+		return nil
+	})
+	flags.BoolVar(&opts.XrefComments, "xref-comments", false, "annotate labels with the addresses of the code that branches to them")
+	flags.BoolVar(&opts.XrefIndex, "xref-index", false, "output an index of all labels and the addresses of the code that branches to them at the end")
 	flags.BoolVar(&opts.ZeroBytes, "z", false, "output the trailing zero bytes of banks")
 }
 
@@ -159,19 +277,20 @@ func getFiles(options *options.Program) ([]string, error) {
 	return files, nil
 }
 
-func disasmFile(logger *log.Logger, opts options.Program, disasmOptions options.Disassembler) error {
+func disasmFile(ctx context.Context, logger *log.Logger, opts options.Program, disasmOptions options.Disassembler) error {
 	file, err := os.Open(opts.Input)
 	if err != nil {
 		return fmt.Errorf("opening file '%s': %w", opts.Input, err)
 	}
 
 	disasmOptions.Binary = opts.Binary
+	disasmOptions.SourceFile = filepath.Base(opts.Input)
 	var cart *cartridge.Cartridge
 
 	if opts.Binary {
 		cart, err = cartridge.LoadBuffer(file)
 	} else {
-		cart, err = cartridge.LoadFile(file)
+		cart, disasmOptions.INESHeader, err = loadINESFile(file)
 	}
 	if err != nil {
 		return fmt.Errorf("reading file: %w", err)
@@ -192,16 +311,22 @@ func disasmFile(logger *log.Logger, opts options.Program, disasmOptions options.
 	if err := openCodeDataLog(opts, disasmOptions); err != nil {
 		return err
 	}
+	if err := readRenameMap(opts, &disasmOptions); err != nil {
+		return err
+	}
+	if err := readKnownFunctions(opts, &disasmOptions); err != nil {
+		return err
+	}
 
 	disasmOptions.HexComments = !opts.NoHexComments
 	disasmOptions.OffsetComments = !opts.NoOffsets
 
-	fileWriterConstructor, paramConverter, err := initializeAssemblerCompatibleMode(opts.Assembler)
+	fileWriterConstructor, syntax, err := initializeAssemblerCompatibleMode(opts.Assembler, disasmOptions.CanonicalOperands)
 	if err != nil {
 		return fmt.Errorf("initializing assembler compatible mode: %w", err)
 	}
 
-	ar := m6502.New(paramConverter)
+	ar := m6502.New(syntax)
 	dis, err := disasm.New(ar, logger, cart, disasmOptions, fileWriterConstructor)
 	if err != nil {
 		return fmt.Errorf("initializing disassembler: %w", err)
@@ -211,10 +336,26 @@ func disasmFile(logger *log.Logger, opts options.Program, disasmOptions options.
 		_ = disasmOptions.CodeDataLog.Close()
 	}
 
-	return processFile(logger, opts, dis)
+	return processFile(ctx, logger, opts, dis)
 }
 
-func processFile(logger *log.Logger, opts options.Program, dis *disasm.Disasm) error {
+func processFile(ctx context.Context, logger *log.Logger, opts options.Program, dis *disasm.Disasm) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if opts.LabelsFile != "" {
+		return writeLabelsFile(ctx, opts, dis)
+	}
+	if opts.CSVFile != "" {
+		return exportCSV(ctx, logger, opts, dis)
+	}
+	if opts.AnalyzeOnly {
+		return analyzeFile(ctx, logger, opts, dis)
+	}
+
 	var (
 		err           error
 		outputFile    io.WriteCloser
@@ -232,7 +373,7 @@ func processFile(logger *log.Logger, opts options.Program, dis *disasm.Disasm) e
 		newBankWriter = newBankWriterFile(opts.Output)
 	}
 
-	app, err := dis.Process(outputFile, newBankWriter)
+	app, err := dis.Process(ctx, outputFile, newBankWriter)
 	if err != nil {
 		return fmt.Errorf("processing file: %w", err)
 	}
@@ -240,6 +381,15 @@ func processFile(logger *log.Logger, opts options.Program, dis *disasm.Disasm) e
 		return fmt.Errorf("closing file: %w", err)
 	}
 
+	if err = writeReports(logger, opts, dis); err != nil {
+		return err
+	}
+	if opts.ExportCSV != "" {
+		if err = writeCSVFile(opts.ExportCSV, app); err != nil {
+			return err
+		}
+	}
+
 	cart := dis.Cart()
 	conf, err := processCa65Config(opts, cart, app)
 	if err != nil {
@@ -250,6 +400,12 @@ func processFile(logger *log.Logger, opts options.Program, dis *disasm.Disasm) e
 		fmt.Println(conf)
 	}
 
+	if opts.EmitMakefile {
+		if err = writeMakefile(opts, cart, app); err != nil {
+			return fmt.Errorf("writing Makefile: %w", err)
+		}
+	}
+
 	if opts.AssembleTest {
 		if err = verification.VerifyOutput(logger, opts, cart, app); err != nil {
 			return fmt.Errorf("output file mismatch: %w", err)
@@ -262,6 +418,145 @@ func processFile(logger *log.Logger, opts options.Program, dis *disasm.Disasm) e
 	return nil
 }
 
+// writeReports logs and writes the enabled reports about the disassembled program.
+func writeReports(logger *log.Logger, opts options.Program, dis *disasm.Disasm) error {
+	if opts.Coverage && !opts.Quiet {
+		logCoverage(logger, dis.Coverage())
+	}
+	if opts.OpcodeHistogram && !opts.Quiet {
+		logOpcodeHistogram(logger, dis.OpcodeHistogram())
+	}
+
+	if opts.Nodes != "" {
+		if err := writeNodesFile(opts.Nodes, dis.Nodes()); err != nil {
+			return fmt.Errorf("writing nodes file: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeNodesFile writes the control flow graph nodes of all instructions as JSON file.
+func writeNodesFile(fileName string, nodes []mapper.Node) error {
+	data, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding nodes: %w", err)
+	}
+	if err := os.WriteFile(fileName, data, 0666); err != nil {
+		return fmt.Errorf("writing file '%s': %w", fileName, err)
+	}
+	return nil
+}
+
+// logCoverage logs the percentage of PRG bytes that were classified as code, code as data,
+// data and data of excluded address ranges.
+func logCoverage(logger *log.Logger, coverage mapper.Coverage) {
+	total := coverage.Total()
+	percentage := func(count int) string {
+		return fmt.Sprintf("%.1f%%", float64(count)*100/float64(total))
+	}
+
+	logger.Info("PRG coverage",
+		log.String("code", percentage(coverage.Code)),
+		log.String("code_as_data", percentage(coverage.CodeAsData)),
+		log.String("data", percentage(coverage.Data)),
+		log.String("excluded", percentage(coverage.Excluded)),
+	)
+}
+
+// logOpcodeHistogram logs the number of decoded instructions for every used opcode.
+func logOpcodeHistogram(logger *log.Logger, histogram []mapper.OpcodeCount) {
+	logger.Info("Opcode histogram")
+	for _, count := range histogram {
+		logger.Info("Opcode",
+			log.String("opcode", fmt.Sprintf("$%02X", count.Opcode)),
+			log.String("instruction", count.Instruction),
+			log.Int("count", count.Count),
+		)
+	}
+}
+
+// writeLabelsFile disassembles the ROM and only writes the labels file, the generation
+// of the assembly output is skipped.
+func writeLabelsFile(ctx context.Context, opts options.Program, dis *disasm.Disasm) error {
+	app, err := dis.Disassemble(ctx)
+	if err != nil {
+		return fmt.Errorf("disassembling file: %w", err)
+	}
+
+	labelsFile, err := os.Create(opts.LabelsFile)
+	if err != nil {
+		return fmt.Errorf("creating file '%s': %w", opts.LabelsFile, err)
+	}
+	if err := symbols.Write(app, labelsFile); err != nil {
+		_ = labelsFile.Close()
+		return fmt.Errorf("writing labels file: %w", err)
+	}
+	if err := labelsFile.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+	return nil
+}
+
+// exportCSV disassembles the ROM and only writes the CSV file, the generation of the assembly
+// output is skipped.
+func exportCSV(ctx context.Context, logger *log.Logger, opts options.Program, dis *disasm.Disasm) error {
+	app, err := dis.Disassemble(ctx)
+	if err != nil {
+		return fmt.Errorf("disassembling file: %w", err)
+	}
+	if err := writeReports(logger, opts, dis); err != nil {
+		return err
+	}
+	return writeCSVFile(opts.CSVFile, app)
+}
+
+// analyzeFile disassembles the program without writing the .asm file and checks the quality of
+// the disassembly. The enabled reports and exports are written before the check.
+func analyzeFile(ctx context.Context, logger *log.Logger, opts options.Program, dis *disasm.Disasm) error {
+	app, err := dis.Disassemble(ctx)
+	if err != nil {
+		return fmt.Errorf("disassembling file: %w", err)
+	}
+
+	if err := writeReports(logger, opts, dis); err != nil {
+		return err
+	}
+	if opts.ExportCSV != "" {
+		if err := writeCSVFile(opts.ExportCSV, app); err != nil {
+			return err
+		}
+	}
+
+	quality := dis.Quality()
+	if !opts.Quiet {
+		logger.Info("Quality",
+			log.String("forced", fmt.Sprintf("%.1f%%", quality.ForcedPercent)),
+			log.Int("stack_imbalances", quality.StackImbalances),
+			log.Int("bank_crossings", quality.BankCrossings),
+		)
+	}
+	if err := quality.Check(opts.Quality); err != nil {
+		return fmt.Errorf("checking quality: %w", err)
+	}
+	return nil
+}
+
+// writeCSVFile writes all offsets of the program as CSV file.
+func writeCSVFile(fileName string, app *program.Program) error {
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("creating file '%s': %w", fileName, err)
+	}
+	if err := csvexport.Write(app, csvFile); err != nil {
+		_ = csvFile.Close()
+		return fmt.Errorf("writing CSV file: %w", err)
+	}
+	if err := csvFile.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+	return nil
+}
+
 func processCa65Config(opts options.Program, cart *cartridge.Cartridge,
 	app *program.Program) (string, error) {
 
@@ -288,6 +583,151 @@ func processCa65Config(opts options.Program, cart *cartridge.Cartridge,
 	return cfg, nil
 }
 
+// writeMakefile writes a Makefile to the directory of the output file that rebuilds the ROM
+// from the output file using the chosen assembler. For ca65 the linker config gets written
+// next to the output file if no config file name was passed. An existing Makefile only gets
+// overwritten if it was generated as well.
+func writeMakefile(opts options.Program, cart *cartridge.Cartridge, app *program.Program) error {
+	if opts.Output == "" {
+		return errors.New("can not emit a Makefile for console output")
+	}
+
+	dir := filepath.Dir(opts.Output)
+	fileName := filepath.Join(dir, "Makefile")
+	if err := checkGeneratedMakefile(fileName); err != nil {
+		return err
+	}
+
+	asmFile := filepath.Base(opts.Output)
+	base := strings.TrimSuffix(asmFile, filepath.Ext(asmFile))
+	romFile := base + ".nes"
+	if filepath.Join(dir, romFile) == filepath.Clean(opts.Input) {
+		romFile = base + "_rebuilt.nes" // do not overwrite the input file
+	}
+
+	var makefile string
+	switch opts.Assembler {
+	case assembler.Asm6:
+		makefile = asm6.Makefile(asmFile, romFile)
+
+	case assembler.Ca65:
+		configFile, err := makefileCa65Config(opts, cart, app, filepath.Join(dir, base+".cfg"))
+		if err != nil {
+			return err
+		}
+		if relativeConfig, err := filepath.Rel(dir, configFile); err == nil {
+			configFile = relativeConfig
+		}
+		makefile = ca65.Makefile(asmFile, configFile, romFile)
+
+	case assembler.Nesasm:
+		makefile = nesasm.Makefile(asmFile, romFile)
+
+	default:
+		return fmt.Errorf("unsupported assembler '%s'", opts.Assembler)
+	}
+
+	if err := os.WriteFile(fileName, []byte(makefile), 0666); err != nil {
+		return fmt.Errorf("writing file '%s': %w", fileName, err)
+	}
+	return nil
+}
+
+// checkGeneratedMakefile returns an error if the Makefile exists and was not generated.
+func checkGeneratedMakefile(fileName string) error {
+	data, err := os.ReadFile(fileName)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("reading file '%s': %w", fileName, err)
+	case !strings.HasPrefix(string(data), assembler.MakefileHeader):
+		return fmt.Errorf("file '%s' exists and was not generated, not overwriting it", fileName)
+	default:
+		return nil
+	}
+}
+
+// makefileCa65Config returns the name of the ca65 linker config file to use in the Makefile.
+// If no config file name was passed, the config gets written to the given default file name.
+func makefileCa65Config(opts options.Program, cart *cartridge.Cartridge, app *program.Program,
+	defaultFile string) (string, error) {
+
+	if opts.Config != "" {
+		return opts.Config, nil
+	}
+
+	cfg, err := ca65.GenerateMapperConfig(ca65.Config{
+		App:     app,
+		PRGSize: len(cart.PRG),
+		CHRSize: len(cart.CHR),
+	})
+	if err != nil {
+		return "", fmt.Errorf("generating ca65 config: %w", err)
+	}
+	if err := os.WriteFile(defaultFile, []byte(cfg), 0666); err != nil {
+		return "", fmt.Errorf("writing ca65 config: %w", err)
+	}
+	return defaultFile, nil
+}
+
+// loadINESFile loads the cartridge of an iNES file and returns it with the raw iNES header,
+// which contains NES 2.0 fields that are not part of the cartridge.
+func loadINESFile(file io.Reader) (*cartridge.Cartridge, []byte, error) {
+	header := make([]byte, program.INESHeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	cart, err := cartridge.LoadFile(io.MultiReader(bytes.NewReader(header), file))
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading cartridge: %w", err)
+	}
+	return cart, header, nil
+}
+
+// readRenameMap reads the label rename map file if one was passed.
+func readRenameMap(opts options.Program, disasmOptions *options.Disassembler) error {
+	if opts.RenameMap == "" {
+		return nil
+	}
+
+	file, err := os.Open(opts.RenameMap)
+	if err != nil {
+		return fmt.Errorf("opening file '%s': %w", opts.RenameMap, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	disasmOptions.Renames, err = options.ParseRenameMap(file)
+	if err != nil {
+		return fmt.Errorf("parsing rename map '%s': %w", opts.RenameMap, err)
+	}
+	return nil
+}
+
+// readKnownFunctions reads the known functions database file if one was passed.
+func readKnownFunctions(opts options.Program, disasmOptions *options.Disassembler) error {
+	if opts.KnownFuncs == "" {
+		return nil
+	}
+
+	file, err := os.Open(opts.KnownFuncs)
+	if err != nil {
+		return fmt.Errorf("opening file '%s': %w", opts.KnownFuncs, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	disasmOptions.KnownFunctions, err = options.ParseKnownFunctions(file)
+	if err != nil {
+		return fmt.Errorf("parsing known functions '%s': %w", opts.KnownFuncs, err)
+	}
+	return nil
+}
+
 func openCodeDataLog(options options.Program, disasmOptions options.Disassembler) error {
 	if options.CodeDataLog == "" {
 		return nil
@@ -307,6 +747,9 @@ func newBankWriterFile(outputFile string) assembler.NewBankWriter {
 
 	return func(baseName string) (io.WriteCloser, error) {
 		fileName := fmt.Sprintf("%s%s%s", base, baseName, ext)
+		if filepath.Ext(baseName) != "" {
+			fileName = filepath.Join(filepath.Dir(outputFile), baseName)
+		}
 		f, err := os.Create(fileName)
 		if err != nil {
 			return nil, fmt.Errorf("creating file '%s': %w", fileName, err)
@@ -316,31 +759,50 @@ func newBankWriterFile(outputFile string) assembler.NewBankWriter {
 }
 
 func newBankWriterStdOut(_ string) (io.WriteCloser, error) {
-	return os.Stdout, nil
+	return nopWriteCloser{Writer: os.Stdout}, nil
+}
+
+// nopWriteCloser wraps a writer that must not be closed, like stdout.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopWriteCloser) Close() error {
+	return nil
 }
 
 // initializeAssemblerCompatibleMode sets the chosen assembler specific instances
-// to be used to output compatible code.
-func initializeAssemblerCompatibleMode(assemblerName string) (disasm.FileWriterConstructor, parameter.Converter, error) {
+// to be used to output compatible code. Canonical operands replace the assembler
+// specific operand syntax by the assembler neutral one.
+func initializeAssemblerCompatibleMode(assemblerName string,
+	canonicalOperands bool) (disasm.FileWriterConstructor, assembler.Syntax, error) {
+
 	var fileWriterConstructor disasm.FileWriterConstructor
-	var paramCfg parameter.Config
+	var syntax assembler.Syntax
 
 	switch strings.ToLower(assemblerName) {
 	case assembler.Asm6:
 		fileWriterConstructor = asm6.New
-		paramCfg = asm6.ParamConfig
+		syntax = asm6.Syntax
 
 	case assembler.Ca65:
 		fileWriterConstructor = ca65.New
-		paramCfg = ca65.ParamConfig
+		syntax = ca65.Syntax
 
 	case assembler.Nesasm:
+		if canonicalOperands {
+			return nil, assembler.Syntax{}, errors.New("canonical operands are not supported by nesasm")
+		}
 		fileWriterConstructor = nesasm.New
-		paramCfg = nesasm.ParamConfig
+		syntax = nesasm.Syntax
 
 	default:
-		return nil, parameter.Converter{}, fmt.Errorf("unsupported assembler '%s'", assemblerName)
+		return nil, assembler.Syntax{}, fmt.Errorf("unsupported assembler '%s'", assemblerName)
 	}
 
-	return fileWriterConstructor, parameter.New(paramCfg), nil
+	if canonicalOperands {
+		syntax = assembler.CanonicalSyntax
+	}
+	return fileWriterConstructor, syntax, nil
 }
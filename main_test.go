@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	disasm "github.com/retroenv/nesgodisasm/internal"
+	"github.com/retroenv/nesgodisasm/internal/assembler"
+	"github.com/retroenv/retrogolib/assert"
+)
+
+// mainArgsEnv is the environment variable that passes the arguments to the main function
+// when the test binary is executed as the disassembler process.
+const mainArgsEnv = "NESGODISASM_MAIN_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(mainArgsEnv); ok {
+		os.Args = append([]string{os.Args[0]}, strings.Split(args, " ")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestAnalyzeOnlyExitCode(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "test.nes")
+	assert.NoError(t, os.WriteFile(rom, testROM(), 0o644))
+
+	tests := []struct {
+		name     string
+		args     string
+		exitCode int
+	}{
+		{"forced bytes below threshold", "-max-forced-percent 15", 0},
+		{"forced bytes above threshold", "-max-forced-percent 10", disasm.ExitCodeForcedBytes},
+	}
+
+	for _, test := range tests {
+		args := "-q -analyze-only -exclude 0x9000-0x9FFF " + test.args + " " + rom
+		assert.Equal(t, test.exitCode, runMain(t, args), test.name)
+	}
+
+	missing := filepath.Join(dir, "missing.nes")
+	assert.Equal(t, exitCodeFailure, runMain(t, "-q -analyze-only "+missing), "unreadable ROM")
+}
+
+func TestOnlyCSVFile(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "test.nes")
+	assert.NoError(t, os.WriteFile(rom, testROM(), 0o644))
+
+	csvFile := filepath.Join(dir, "test.csv")
+	assert.Equal(t, 0, runMain(t, "-q -only-csv-file "+csvFile+" "+rom))
+
+	data, err := os.ReadFile(csvFile)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "bank,address,type,label,code,comment\n0,$8000,code,Reset,rti,"))
+
+	_, err = os.Stat(filepath.Join(dir, "test.asm"))
+	assert.True(t, os.IsNotExist(err), "no .asm file should be written")
+}
+
+func TestInitializeAssemblerCompatibleMode(t *testing.T) {
+	_, specific, err := initializeAssemblerCompatibleMode(assembler.Asm6, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "a:", specific.Params.AbsolutePrefix)
+
+	_, canonical, err := initializeAssemblerCompatibleMode(assembler.Asm6, true)
+	assert.NoError(t, err)
+	assert.Equal(t, assembler.CanonicalSyntax, canonical)
+
+	_, canonical, err = initializeAssemblerCompatibleMode(assembler.Ca65, true)
+	assert.NoError(t, err)
+	assert.Equal(t, assembler.CanonicalSyntax, canonical)
+
+	_, _, err = initializeAssemblerCompatibleMode(assembler.Nesasm, true)
+	assert.Error(t, err, "canonical operands are not supported by nesasm")
+
+	_, _, err = initializeAssemblerCompatibleMode("unknown", false)
+	assert.Error(t, err, "unsupported assembler 'unknown'")
+}
+
+func TestCanonicalOperands(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "test.nes")
+	data := testROM()
+	copy(data[16:], []byte{0x6c, 0x00, 0x02}) // jmp ($0200)
+	assert.NoError(t, os.WriteFile(rom, data, 0o644))
+
+	output := filepath.Join(dir, "test.asm")
+	assert.Equal(t, 0, runMain(t, "-q -a asm6 -canonicalize-operands -o "+output+" "+rom))
+	asm, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(asm), "jmp ($0200)"))
+	assert.True(t, strings.Contains(string(asm), assembler.CanonicalOperandsDescription))
+
+	// nesasm can not assemble the canonical syntax
+	output = filepath.Join(dir, "nesasm.asm")
+	assert.Equal(t, 0, runMain(t, "-q -a nesasm -canonicalize-operands -o "+output+" "+rom))
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err), "no .asm file should be written")
+}
+
+func TestEmitMakefile(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "test.nes")
+	assert.NoError(t, os.WriteFile(rom, testROM(), 0o644))
+
+	makefile := filepath.Join(dir, "Makefile")
+	args := "-q -a asm6 -emit-makefile -o " + filepath.Join(dir, "game.asm") + " " + rom
+	assert.Equal(t, 0, runMain(t, args))
+	data, err := os.ReadFile(makefile)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), assembler.MakefileHeader))
+	assert.True(t, strings.Contains(string(data), "game.nes: game.asm\n"))
+
+	// a generated Makefile gets regenerated
+	assert.NoError(t, os.WriteFile(makefile, []byte(assembler.MakefileHeader+"\nstale\n"), 0o644))
+	assert.Equal(t, 0, runMain(t, args))
+	regenerated, err := os.ReadFile(makefile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(regenerated))
+
+	// a Makefile that was not generated is kept
+	custom := []byte("all:\n\techo custom\n")
+	assert.NoError(t, os.WriteFile(makefile, custom, 0o644))
+	runMain(t, args)
+	data, err = os.ReadFile(makefile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(custom), string(data))
+}
+
+// runMain executes the test binary as disassembler process with the given arguments and returns
+// the exit code of the process.
+func runMain(t *testing.T, args string) int {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), mainArgsEnv+"="+args)
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	assert.NoError(t, err)
+	return 0
+}
+
+// testROM returns an iNES ROM with 32KB PRG-ROM that only contains a reset handler at $8000.
+func testROM() []byte {
+	header := []byte{'N', 'E', 'S', 0x1a, 0x02, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	prg := make([]byte, 0x8000)
+	prg[0] = 0x40 // rti
+	prg[0x7ffd] = 0x80
+
+	rom := append(header, prg...)
+	return append(rom, make([]byte, 0x2000)...)
+}